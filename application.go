@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/gotk3/gotk3/glib"
 	"github.com/gotk3/gotk3/gtk"
@@ -13,7 +15,10 @@ import (
 	"go.elara.ws/go-lemmy"
 )
 
-const applicationName = "io.github.mjdiliscia.lemmeread"
+const (
+	applicationName  = "io.github.mjdiliscia.lemmeread"
+	myUserRetryDelay = 30 * time.Second
+)
 
 type Application struct {
 	LemmyClient    *lemmy.Client
@@ -22,6 +27,15 @@ type Application struct {
 	View           view.MainView
 	Model          model.AppModel
 	Controller     controller.PostsController
+	CreatePost     controller.CreatePostController
+	Search         controller.SearchController
+	Communities    controller.CommunitiesController
+	Profile        controller.ProfileController
+	Inbox          controller.InboxController
+	Messages       controller.MessagesController
+
+	loginView   *view.LoginView
+	myUserRetry glib.SourceHandle
 }
 
 func NewApplication() (app Application, err error) {
@@ -38,7 +52,11 @@ func NewApplication() (app Application, err error) {
 func (app *Application) onActivate() {
 	app.initAppModel()
 	if app.Model.Configuration.HaveLemmyData() {
-		app.initMainView()
+		err := app.initMainView()
+		if err != nil {
+			app.showStartupError(err)
+			return
+		}
 		app.setupControllers()
 		app.lemmyStartup()
 	} else {
@@ -48,20 +66,44 @@ func (app *Application) onActivate() {
 
 func (app *Application) initAppModel() {
 	app.Model.Init()
+	app.Model.SessionExpired = app.onSessionExpired
 }
 
-func (app *Application) initMainView() {
+func (app *Application) initMainView() error {
 	log.Println("About to setup MainWindow...")
 	err := app.View.SetupMainView(&app.Model)
 	if err != nil {
-		log.Panic(err)
+		return fmt.Errorf("Couldn't setup MainWindow: %s", err)
 	}
 	app.View.Window.SetApplication(app.GtkApplication)
 	log.Println("MainWindow setup finished.")
+	return nil
+}
+
+// showStartupError is for the errors that leave no window to work with, so
+// all that's left is to tell why and quit.
+func (app *Application) showStartupError(err error) {
+	log.Println(err)
+	dialog := gtk.MessageDialogNew(nil, gtk.DIALOG_MODAL, gtk.MESSAGE_ERROR, gtk.BUTTONS_CLOSE, "Lemme Read couldn't start: %s", err)
+	dialog.SetApplication(app.GtkApplication)
+	dialog.Run()
+	dialog.Destroy()
+	app.GtkApplication.Quit()
 }
 
 func (app *Application) setupControllers() {
 	app.Controller.Init(&app.View, &app.Model)
+	app.CreatePost.Init(&app.View, &app.Model)
+	app.Search.Init(&app.View, &app.Model)
+	app.Communities.Init(&app.View, &app.Model)
+	app.Profile.Init(&app.View, &app.Model)
+	app.Inbox.Init(&app.View, &app.Model)
+	app.Messages.Init(&app.View, &app.Model)
+
+	app.View.AccountSelected = app.onAccountSelected
+	app.View.AddAccountClicked = app.onAddAccountClicked
+	app.View.LogInClicked = app.onAddAccountClicked
+	app.View.ClearCacheClicked = app.onClearCacheClicked
 }
 
 func (app *Application) lemmyStartup() {
@@ -74,27 +116,276 @@ func (app *Application) initLoginView() {
 	var loginView view.LoginView
 	err := loginView.SetupLoginView()
 	if err != nil {
-		log.Panic(err)
+		app.showStartupError(fmt.Errorf("Couldn't setup the login dialog: %s", err))
+		return
 	}
 	loginView.Window.SetApplication(app.GtkApplication)
-	loginView.LoginClicked = func(server string, username string, password string) {
-		loginView.DestroyWindow()
-		app.initMainView()
+	app.connectLoginView(&loginView, func() {
+		err := app.initMainView()
+		if err != nil {
+			app.showStartupError(err)
+			return
+		}
 		app.setupControllers()
-		app.Model.InitializeLemmyClientWithLogin(server, username, password, app.onLemmyStarted)
+		app.onLemmyStarted(nil)
+	})
+}
+
+// connectLoginView wires the login dialog, and the sign up dialog it can open,
+// to the model. loggedIn runs once either of them leaves a new session ready.
+func (app *Application) connectLoginView(loginView *view.LoginView, loggedIn func()) {
+	var instance model.InstanceModel
+	loginView.ServerChanged = func(server string) {
+		app.Model.DiscoverInstance(server, func(discovered model.InstanceModel, err error) {
+			if err != nil {
+				log.Println(err)
+				loginView.SetInstanceError(server, err.Error())
+				return
+			}
+			if discovered.URL == server {
+				instance = discovered
+			}
+			loginView.SetInstance(discovered)
+		})
+	}
+	loginView.SignUpClicked = func(server string) {
+		if instance.URL == server {
+			app.openRegisterView(loginView, instance, loggedIn)
+		}
+	}
+	loginView.BrowseClicked = func(server string) {
+		err := app.Model.InitializeAnonymousClient(server)
+		if err != nil {
+			log.Println(err)
+			loginView.ShowMessage(err.Error())
+			return
+		}
+		loginView.DestroyWindow()
+		loggedIn()
+	}
+	loginView.LoginClicked = func(server string, username string, password string, totp string) {
+		app.Model.InitializeLemmyClientWithLogin(server, username, password, totp, func(err error) {
+			if err != nil {
+				showLoginError(loginView, err)
+				return
+			}
+			loginView.DestroyWindow()
+			loggedIn()
+		})
+	}
+}
+
+func (app *Application) openRegisterView(loginView *view.LoginView, instance model.InstanceModel, loggedIn func()) {
+	var registerView view.RegisterView
+	err := registerView.SetupRegisterView(&loginView.Window.Window)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	registerView.SetInstance(instance)
+
+	refreshCaptcha := func() {
+		if !instance.CaptchaEnabled {
+			return
+		}
+		app.Model.RetrieveCaptcha(instance.URL, func(captcha model.CaptchaModel, err error) {
+			if err != nil {
+				log.Println(err)
+				registerView.ShowMessage(fmt.Sprintf("Couldn't load the captcha: %s", err))
+				return
+			}
+			registerView.SetCaptcha(captcha)
+		})
+	}
+	refreshCaptcha()
+
+	registerView.RefreshCaptchaClicked = refreshCaptcha
+	registerView.RegisterClicked = func(registration model.Registration) {
+		app.Model.RegisterAccount(instance.URL, registration, func(err error) {
+			switch {
+			case err == nil:
+				registerView.DestroyWindow()
+				loginView.DestroyWindow()
+				loggedIn()
+			case errors.Is(err, model.ErrEmailNotVerified):
+				registerView.DestroyWindow()
+				loginView.ShowMessage("Account created. Follow the link the instance emailed you to verify your address, then log in.")
+			case errors.Is(err, model.ErrRegistrationPending):
+				registerView.DestroyWindow()
+				loginView.ShowMessage("Application sent. You'll be able to log in once the instance admins approve it.")
+			default:
+				showRegisterError(&registerView, err)
+				// Lemmy forgets a captcha once it's been checked, right or wrong.
+				refreshCaptcha()
+			}
+		})
+	}
+}
+
+func (app *Application) onAccountSelected(index int) {
+	log.Printf("Switching to account %d...", index)
+	err := app.Model.SwitchAccount(index)
+	app.View.ResetView()
+	app.onLemmyStarted(err)
+}
+
+func (app *Application) onClearCacheClicked() {
+	err := app.Model.ClearImageCache()
+	if err != nil {
+		log.Println(err)
+		app.View.ShowError(fmt.Sprintf("Couldn't clear the image cache: %s", err), app.onClearCacheClicked)
+		return
+	}
+	app.View.ShowNotice(gtk.MESSAGE_INFO, "Image cache cleared.", "", nil)
+}
+
+func (app *Application) onAddAccountClicked() {
+	app.openLoginView("", "")
+}
+
+// openLoginView shows the login dialog over the main window, with server and
+// username already filled in when they aren't empty. There's only ever one.
+func (app *Application) openLoginView(server string, username string) {
+	if app.loginView != nil {
+		app.loginView.Window.Present()
+		return
+	}
+
+	loginView := &view.LoginView{}
+	err := loginView.SetupLoginView()
+	if err != nil {
+		log.Println(err)
+		app.View.ShowError(fmt.Sprintf("Couldn't open the login dialog: %s", err), nil)
+		return
 	}
+	loginView.Window.SetTransientFor(app.View.Window)
+	loginView.Window.Connect("destroy", func() {
+		app.loginView = nil
+	})
+	loginView.SetServer(server, username)
+	app.connectLoginView(loginView, func() {
+		app.View.ResetView()
+		app.onLemmyStarted(nil)
+	})
+	app.loginView = loginView
+}
+
+// onSessionExpired drops back to the login dialog for the current account once
+// the server stops accepting its token.
+func (app *Application) onSessionExpired() {
+	if app.View.Window == nil {
+		return
+	}
+
+	if app.Model.Configuration.IsAnonymous() {
+		app.View.ShowNotice(gtk.MESSAGE_INFO, "You need an account to do that.", "Log in", app.onAddAccountClicked)
+		return
+	}
+
+	app.Model.StopInboxPolling()
+	server := app.Model.Configuration.GetLemmyServer()
+	username := app.Model.Configuration.GetUsername()
+	relogin := func() { app.openLoginView(server, username) }
+	app.View.ShowNotice(gtk.MESSAGE_WARNING, fmt.Sprintf("Your session on %s expired, log in again to keep using it.", server), "Log in", relogin)
+	relogin()
 }
 
 func (app *Application) onLemmyStarted(err error) {
 	if err != nil {
-		log.Panic(err)
+		log.Println(err)
+		server := app.Model.Configuration.GetLemmyServer()
+		app.View.ShowNotice(gtk.MESSAGE_ERROR, fmt.Sprintf("Couldn't connect to %s: %s", server, err), "Log in", func() {
+			app.openLoginView(server, app.Model.Configuration.GetUsername())
+		})
+		return
 	}
 	log.Println("Initialization finished.")
+	if !app.Model.Configuration.IsAnonymous() {
+		app.Inbox.StartPolling()
+	}
+	app.retrieveMyUser()
 	log.Println("About to retrieve first page of posts...")
 	app.Model.RetrieveMorePosts(func(err error) {
 		if err != nil {
-			log.Panic(err)
+			log.Println(err)
+			// Switching accounts discards any retrieval still in flight, and expired
+			// sessions are already taken care of.
+			if !errors.Is(err, context.Canceled) && !errors.Is(err, model.ErrSessionExpired) {
+				app.View.ShowError(fmt.Sprintf("Couldn't reach %s: %s", app.Model.Configuration.GetLemmyServer(), err), func() {
+					app.onLemmyStarted(nil)
+				})
+			}
+			return
 		}
 		log.Println("Inital posts retrieval finished.")
 	})
 }
+
+// retrieveMyUser keeps trying until the user of the current account is known,
+// which the inbox needs to tell received messages from sent ones.
+func (app *Application) retrieveMyUser() {
+	if app.myUserRetry != 0 {
+		glib.SourceRemove(app.myUserRetry)
+		app.myUserRetry = 0
+	}
+
+	app.Model.RetrieveMyUser(func(err error) {
+		if errors.Is(err, model.ErrAccountSwitched) {
+			return
+		}
+		if err != nil {
+			log.Printf("Couldn't retrieve the user, trying again in %s: %s", myUserRetryDelay, err)
+			app.myUserRetry = glib.TimeoutAdd(uint(myUserRetryDelay.Milliseconds()), func() bool {
+				app.myUserRetry = 0
+				app.retrieveMyUser()
+				return false
+			})
+			return
+		}
+		app.View.RefreshAccounts()
+	})
+}
+
+func showLoginError(loginView *view.LoginView, err error) {
+	log.Println(err)
+	loginView.SetBusy(false)
+
+	switch {
+	case errors.Is(err, model.ErrMissingTOTP):
+		loginView.AskForTOTP("This account uses two-factor authentication. Enter the code from your authenticator app.")
+	case errors.Is(err, model.ErrIncorrectTOTP):
+		loginView.AskForTOTP("That two-factor code wasn't accepted. Enter the current one from your authenticator app.")
+	case errors.Is(err, model.ErrIncorrectLogin):
+		loginView.ShowMessage("Wrong username or password.")
+	case errors.Is(err, model.ErrEmailNotVerified):
+		loginView.ShowMessage("Your email address isn't verified yet. Follow the link the instance emailed you, then log in again.")
+	case errors.Is(err, model.ErrRegistrationPending):
+		loginView.ShowMessage("Your registration is waiting for the instance admins to approve it. Try again once you get their email.")
+	case errors.Is(err, model.ErrRegistrationDenied):
+		loginView.ShowMessage("The instance admins denied your registration application.")
+	default:
+		loginView.ShowMessage(fmt.Sprintf("Couldn't log in: %s", err))
+	}
+}
+
+func showRegisterError(registerView *view.RegisterView, err error) {
+	log.Println(err)
+	registerView.SetBusy(false)
+
+	switch {
+	case errors.Is(err, model.ErrCaptchaIncorrect):
+		registerView.ShowMessage("The captcha answer was wrong, try this new one.")
+	case errors.Is(err, model.ErrUsernameTaken):
+		registerView.ShowMessage("That username is already taken.")
+	case errors.Is(err, model.ErrPasswordsDontMatch):
+		registerView.ShowMessage("The passwords don't match.")
+	case errors.Is(err, model.ErrEmailRequired):
+		registerView.ShowMessage("This instance requires an email address.")
+	case errors.Is(err, model.ErrAnswerRequired):
+		registerView.ShowMessage("Answer the instance's application question.")
+	case errors.Is(err, model.ErrRegistrationClosed):
+		registerView.ShowMessage("This instance isn't accepting new accounts.")
+	default:
+		registerView.ShowMessage(fmt.Sprintf("Couldn't create the account: %s", err))
+	}
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/view"
+)
+
+type CommunitiesController struct {
+	mainView *view.MainView
+	appModel *model.AppModel
+}
+
+func (cc *CommunitiesController) Init(mv *view.MainView, am *model.AppModel) {
+	cc.mainView = mv
+	cc.appModel = am
+
+	mv.CommunitiesClicked = cc.onCommunitiesClicked
+	mv.CommunitiesBottomReached = cc.onCommunitiesBottomReached
+	mv.CommunitiesOrderChanged = cc.onCommunitiesOrderChanged
+	mv.CommunitiesFilterChanged = cc.onCommunitiesFilterChanged
+	mv.SubscribeClicked = cc.onSubscribeClicked
+	mv.ShowCommunityPostsClicked = cc.onShowCommunityPostsClicked
+	mv.CommunityClicked = cc.onCommunityClicked
+	mv.CommunityPageBottomReached = cc.onCommunityPageBottomReached
+}
+
+func (cc *CommunitiesController) onCommunitiesClicked() {
+	cc.appModel.Communities.CleanList()
+	cc.mainView.OpenCommunities()
+	cc.retrieveMoreCommunities()
+}
+
+func (cc *CommunitiesController) onCommunitiesBottomReached() {
+	cc.retrieveMoreCommunities()
+}
+
+func (cc *CommunitiesController) onCommunitiesOrderChanged(newOrder int) {
+	cc.appModel.Communities.Order = model.CommunitiesOrder(newOrder)
+	cc.restartList()
+}
+
+func (cc *CommunitiesController) onCommunitiesFilterChanged(newFilter int) {
+	cc.appModel.Communities.Filter = model.PostsFilter(newFilter)
+	cc.restartList()
+}
+
+func (cc *CommunitiesController) onSubscribeClicked(communityID int64, follow bool) {
+	cc.mainView.SetCommunityBusy(communityID, true)
+	cc.appModel.FollowCommunity(communityID, follow, func(err error) {
+		cc.mainView.SetCommunityBusy(communityID, false)
+		if err != nil {
+			showError(cc.mainView, "update the subscription", err, nil)
+			return
+		}
+		cc.mainView.UpdateCommunity(communityID)
+	})
+}
+
+func (cc *CommunitiesController) onShowCommunityPostsClicked(communityID int64) {
+	community := cc.appModel.Communities.KnownCommunities[communityID]
+	cc.mainView.GoBackToRoot()
+	cc.mainView.SetFeedSubtitle(community.Community.Title)
+	cc.mainView.CleanView()
+	cc.appModel.SetFeedCommunity(communityID)
+	cc.appModel.RetrieveMorePosts(func(err error) {
+		if err != nil {
+			showError(cc.mainView, "load the community posts", err, func() { cc.onShowCommunityPostsClicked(communityID) })
+		}
+	})
+}
+
+func (cc *CommunitiesController) onCommunityClicked(communityID int64) {
+	cc.appModel.OpenCommunityPage(communityID)
+	cc.mainView.OpenCommunityPage()
+	cc.appModel.RetrieveCommunity(communityID, func(err error) {
+		if err != nil {
+			showError(cc.mainView, "load the community", err, nil)
+			return
+		}
+		cc.mainView.ShowCommunityPageHeader()
+	})
+	cc.retrieveMoreCommunityPosts()
+}
+
+func (cc *CommunitiesController) onCommunityPageBottomReached() {
+	cc.retrieveMoreCommunityPosts()
+}
+
+func (cc *CommunitiesController) restartList() {
+	if cc.mainView.CommunityListView != nil {
+		cc.mainView.CommunityListView.CleanView()
+	}
+	cc.appModel.Communities.CleanList()
+	cc.retrieveMoreCommunities()
+}
+
+func (cc *CommunitiesController) retrieveMoreCommunities() {
+	cc.appModel.RetrieveMoreCommunities(func(err error) {
+		if err != nil {
+			showError(cc.mainView, "load communities", err, cc.retrieveMoreCommunities)
+		}
+	})
+}
+
+func (cc *CommunitiesController) retrieveMoreCommunityPosts() {
+	cc.appModel.RetrieveMoreCommunityPosts(func(err error) {
+		if err != nil {
+			showError(cc.mainView, "load the community posts", err, cc.retrieveMoreCommunityPosts)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/view"
+)
+
+type CreatePostController struct {
+	mainView       *view.MainView
+	appModel       *model.AppModel
+	createPostView *view.CreatePostView
+}
+
+func (cpc *CreatePostController) Init(mv *view.MainView, am *model.AppModel) {
+	cpc.mainView = mv
+	cpc.appModel = am
+
+	mv.NewPostClicked = cpc.onNewPostClicked
+}
+
+func (cpc *CreatePostController) onNewPostClicked() {
+	if cpc.createPostView != nil {
+		cpc.createPostView.Window.Present()
+		return
+	}
+
+	cpc.createPostView = &view.CreatePostView{}
+	err := cpc.createPostView.SetupCreatePostView(cpc.mainView.Window)
+	if err != nil {
+		log.Println(err)
+		cpc.createPostView = nil
+		return
+	}
+
+	cpc.createPostView.CommunitySearchChanged = cpc.onCommunitySearchChanged
+	cpc.createPostView.UploadClicked = cpc.onUploadClicked
+	cpc.createPostView.Submitted = cpc.onSubmitted
+	cpc.createPostView.Cancelled = cpc.closeCreatePostView
+	cpc.createPostView.Window.Connect("delete-event", func() bool {
+		cpc.closeCreatePostView()
+		return true
+	})
+}
+
+func (cpc *CreatePostController) onCommunitySearchChanged(query string) {
+	cpc.appModel.SearchCommunities(query, func(communities []model.CommunityModel, err error) {
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if cpc.createPostView != nil {
+			cpc.createPostView.SetCommunities(communities)
+		}
+	})
+}
+
+func (cpc *CreatePostController) onUploadClicked(filePath string) {
+	cpc.createPostView.SetBusy(true)
+	cpc.createPostView.SetStatus("Uploading image...")
+	cpc.appModel.UploadImage(filePath, func(imageUrl string, err error) {
+		if cpc.createPostView == nil {
+			return
+		}
+
+		cpc.createPostView.SetBusy(false)
+		if err != nil {
+			log.Println(err)
+			cpc.createPostView.SetStatus(fmt.Sprintf("Couldn't upload image: %s", err))
+			return
+		}
+		cpc.createPostView.SetStatus("")
+		cpc.createPostView.SetImageUrl(imageUrl)
+	})
+}
+
+func (cpc *CreatePostController) onSubmitted(communityID int64, title string, url string, body string, nsfw bool) {
+	cpc.createPostView.SetBusy(true)
+	cpc.appModel.CreatePost(communityID, title, url, body, nsfw, func(err error) {
+		if cpc.createPostView == nil {
+			return
+		}
+
+		if err != nil {
+			log.Println(err)
+			cpc.createPostView.SetBusy(false)
+			cpc.createPostView.SetStatus(fmt.Sprintf("Couldn't create post: %s", err))
+			return
+		}
+		cpc.closeCreatePostView()
+		cpc.mainView.ScrollToTop()
+	})
+}
+
+func (cpc *CreatePostController) closeCreatePostView() {
+	if cpc.createPostView == nil {
+		return
+	}
+
+	cpc.createPostView.DestroyWindow()
+	cpc.createPostView = nil
+}
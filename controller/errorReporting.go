@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/view"
+)
+
+// showError logs err and tells the user what failed in the main window info
+// bar, with a retry button when retry isn't nil. Expired sessions are left to
+// the application, which asks to log in again, and cancelled requests only
+// happen on purpose when switching accounts.
+func showError(mv *view.MainView, action string, err error, retry func()) {
+	log.Println(err)
+	if errors.Is(err, model.ErrSessionExpired) || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	mv.ShowError(fmt.Sprintf("Couldn't %s: %s", action, describeError(err)), retry)
+}
+
+func describeError(err error) string {
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return "the server took too long to answer."
+	}
+
+	var urlError *url.Error
+	if errors.As(err, &urlError) {
+		return "the server can't be reached. Check your connection."
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/view"
+)
+
+type InboxController struct {
+	mainView *view.MainView
+	appModel *model.AppModel
+}
+
+func (ic *InboxController) Init(mv *view.MainView, am *model.AppModel) {
+	ic.mainView = mv
+	ic.appModel = am
+
+	mv.InboxClicked = ic.onInboxClicked
+	mv.InboxBottomReached = ic.onInboxBottomReached
+	mv.InboxUnreadOnlyToggled = ic.onInboxUnreadOnlyToggled
+	mv.InboxMarkAllReadClicked = ic.onInboxMarkAllReadClicked
+	mv.InboxItemOpened = ic.onInboxItemOpened
+	mv.InboxItemMarkRead = ic.onInboxItemMarkRead
+}
+
+func (ic *InboxController) StartPolling() {
+	ic.appModel.StartInboxPolling()
+}
+
+func (ic *InboxController) onInboxClicked() {
+	ic.appModel.OpenInbox(ic.appModel.Inbox.UnreadOnly)
+	ic.mainView.OpenInbox()
+	ic.retrieveMoreItems()
+}
+
+func (ic *InboxController) onInboxBottomReached() {
+	ic.retrieveMoreItems()
+}
+
+func (ic *InboxController) onInboxUnreadOnlyToggled(unreadOnly bool) {
+	ic.appModel.OpenInbox(unreadOnly)
+	if ic.mainView.InboxView != nil {
+		ic.mainView.InboxView.CleanView()
+	}
+	ic.retrieveMoreItems()
+}
+
+func (ic *InboxController) onInboxMarkAllReadClicked() {
+	ic.appModel.MarkAllInboxRead(func(err error) {
+		if err != nil {
+			showError(ic.mainView, "mark the inbox as read", err, ic.onInboxMarkAllReadClicked)
+			return
+		}
+		ic.mainView.SetInboxAllRead()
+	})
+}
+
+func (ic *InboxController) onInboxItemOpened(item model.InboxItem) {
+	if !item.Read {
+		ic.onInboxItemMarkRead(item)
+	}
+
+	if item.Kind == model.InboxItemMessage {
+		ic.appModel.OpenConversation(item.Author, func(err error) {
+			if err != nil {
+				showError(ic.mainView, "load the conversation", err, nil)
+				return
+			}
+			ic.mainView.OpenConversation(item.Author.ID)
+			ic.appModel.MarkConversationRead(item.Author.ID)
+		})
+		return
+	}
+
+	ic.appModel.RetrievePost(item.PostID, func(err error) {
+		if err != nil {
+			showError(ic.mainView, "load the post", err, nil)
+			return
+		}
+
+		ic.appModel.RetrieveComments(item.PostID, func(err error) {
+			if err != nil {
+				showError(ic.mainView, "load the comments", err, nil)
+				return
+			}
+			ic.mainView.OpenComments(item.PostID)
+			ic.mainView.ScrollToComment(item.CommentID)
+		})
+	})
+}
+
+func (ic *InboxController) onInboxItemMarkRead(item model.InboxItem) {
+	ic.mainView.SetInboxItemBusy(item.Kind, item.ID, true)
+	ic.appModel.MarkInboxItemRead(item.Kind, item.ID, func(err error) {
+		ic.mainView.SetInboxItemBusy(item.Kind, item.ID, false)
+		if err != nil {
+			showError(ic.mainView, "mark the item as read", err, nil)
+			return
+		}
+		ic.mainView.SetInboxItemRead(item.Kind, item.ID)
+	})
+}
+
+func (ic *InboxController) retrieveMoreItems() {
+	ic.appModel.RetrieveMoreInboxItems(func(err error) {
+		if err != nil {
+			showError(ic.mainView, "load the inbox", err, ic.retrieveMoreItems)
+			return
+		}
+		ic.mainView.InboxFinished()
+	})
+}
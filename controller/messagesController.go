@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"log"
+
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/view"
+)
+
+type MessagesController struct {
+	mainView *view.MainView
+	appModel *model.AppModel
+}
+
+func (mc *MessagesController) Init(mv *view.MainView, am *model.AppModel) {
+	mc.mainView = mv
+	mc.appModel = am
+
+	mv.MessagesClicked = mc.onMessagesClicked
+	mv.MessagesBottomReached = mc.onMessagesBottomReached
+	mv.ConversationClicked = mc.onConversationClicked
+	mv.MessageUserClicked = mc.onMessageUserClicked
+	mv.MessageSent = mc.onMessageSent
+	mv.MessageEdited = mc.onMessageEdited
+	mv.MessageDeleted = mc.onMessageDeleted
+}
+
+func (mc *MessagesController) onMessagesClicked() {
+	mc.appModel.OpenMessages()
+	mc.mainView.OpenMessages()
+	mc.retrieveMoreMessages()
+}
+
+func (mc *MessagesController) onMessagesBottomReached() {
+	mc.retrieveMoreMessages()
+}
+
+func (mc *MessagesController) onConversationClicked(counterpartID int64) {
+	mc.mainView.OpenConversation(counterpartID)
+	mc.appModel.MarkConversationRead(counterpartID)
+}
+
+func (mc *MessagesController) onMessageUserClicked(personID int64) {
+	person := mc.appModel.Profile.Person.Person
+	if person.ID != personID {
+		log.Printf("Person %d isn't the one on the profile page, can't message them.", personID)
+		return
+	}
+
+	mc.appModel.OpenConversation(person, func(err error) {
+		if err != nil {
+			showError(mc.mainView, "load the conversation", err, nil)
+			return
+		}
+		mc.onConversationClicked(personID)
+	})
+}
+
+func (mc *MessagesController) onMessageSent(recipientID int64, text string) {
+	mc.mainView.SetMessageComposerBusy(0, true)
+	mc.appModel.SendPrivateMessage(recipientID, text, func(err error) {
+		mc.mainView.SetMessageComposerBusy(0, false)
+		if err != nil {
+			showError(mc.mainView, "send the message", err, nil)
+			return
+		}
+		mc.mainView.MessageSentSuccessfully()
+	})
+}
+
+func (mc *MessagesController) onMessageEdited(messageID int64, text string) {
+	mc.mainView.SetMessageComposerBusy(messageID, true)
+	mc.appModel.EditPrivateMessage(messageID, text, func(err error) {
+		mc.mainView.SetMessageComposerBusy(messageID, false)
+		if err != nil {
+			showError(mc.mainView, "edit the message", err, nil)
+		}
+	})
+}
+
+func (mc *MessagesController) onMessageDeleted(messageID int64) {
+	mc.appModel.DeletePrivateMessage(messageID, func(err error) {
+		if err != nil {
+			showError(mc.mainView, "delete the message", err, nil)
+		}
+	})
+}
+
+func (mc *MessagesController) retrieveMoreMessages() {
+	mc.appModel.RetrieveMoreMessages(func(err error) {
+		if err != nil {
+			showError(mc.mainView, "load the messages", err, mc.retrieveMoreMessages)
+			return
+		}
+		mc.mainView.MessagesFinished()
+	})
+}
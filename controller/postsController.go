@@ -18,51 +18,161 @@ func (pc *PostsController) Init(mv *view.MainView, am *model.AppModel) {
 
 	mv.PostListBottomReached = pc.onPostListBottomReached
 	mv.PostListView.CommentClicked = pc.onCommentsClicked
-	mv.CloseCommentsClicked = pc.onCloseCommentsClicked
+	mv.SearchView.PostListView.CommentClicked = pc.onCommentsClicked
+	mv.BackClicked = pc.onBackClicked
 	mv.OrderChanged = pc.onOrderChanged
 	mv.FilterChanged = pc.onFilterChanged
+	mv.PostVotesChanged = pc.onPostVotesChanged
+	mv.CommentVotesChanged = pc.onCommentVotesChanged
+	mv.PostSaveClicked = pc.onPostSaveClicked
+	mv.PostMediaNeeded = pc.onPostMediaNeeded
+	mv.CommentSaveClicked = pc.onCommentSaveClicked
+	mv.SavedClicked = pc.onSavedClicked
+	mv.SavedBottomReached = pc.onSavedBottomReached
+	mv.CommentSubmitted = pc.onCommentSubmitted
+	mv.CommentEdited = pc.onCommentEdited
+	mv.CommentDeleted = pc.onCommentDeleted
 }
 
 func (pc *PostsController) onPostListBottomReached() {
-	pc.appModel.RetrieveMorePosts(func(err error) {
+	pc.retrieveMorePosts()
+}
+
+func (pc *PostsController) onCommentsClicked(id int64) {
+	pc.appModel.RetrieveComments(id, func(err error) {
 		if err != nil {
-			log.Println(err)
+			showError(pc.mainView, "load the comments", err, func() { pc.onCommentsClicked(id) })
+			return
 		}
+		pc.mainView.OpenComments(id)
 	})
 }
 
-func (pc *PostsController) onCommentsClicked(id int64) {
-	pc.appModel.RetrieveComments(id, func(err error) {
+func (pc *PostsController) onBackClicked() {
+	pc.mainView.GoBack()
+}
+
+func (pc *PostsController) onPostVotesChanged(postID int64, score int64) {
+	pc.appModel.VotePost(postID, score, func(err error) {
+		if err != nil {
+			showError(pc.mainView, "vote", err, nil)
+		}
+		pc.mainView.UpdatePostVotes(postID)
+	})
+	pc.mainView.UpdatePostVotes(postID)
+}
+
+func (pc *PostsController) onCommentVotesChanged(postID int64, commentID int64, score int64) {
+	pc.appModel.VoteComment(postID, commentID, score, func(err error) {
+		if err != nil {
+			showError(pc.mainView, "vote", err, nil)
+		}
+		pc.mainView.UpdateCommentVotes(postID, commentID)
+	})
+	pc.mainView.UpdateCommentVotes(postID, commentID)
+}
+
+func (pc *PostsController) onPostSaveClicked(postID int64, save bool) {
+	pc.appModel.SavePost(postID, save, func(err error) {
+		if err != nil {
+			showError(pc.mainView, "update the saved posts", err, nil)
+		}
+		pc.mainView.UpdatePostSaved(postID)
+	})
+	pc.mainView.UpdatePostSaved(postID)
+}
+
+func (pc *PostsController) onPostMediaNeeded(postID int64) {
+	pc.appModel.LoadPostMedia(postID, func(err error) {
 		if err != nil {
 			log.Println(err)
 			return
 		}
-		pc.mainView.OpenComments(id)
+		pc.mainView.UpdatePostMedia(postID)
+	})
+}
+
+func (pc *PostsController) onCommentSaveClicked(postID int64, commentID int64, save bool) {
+	pc.appModel.SaveComment(postID, commentID, save, func(err error) {
+		if err != nil {
+			showError(pc.mainView, "update the saved comments", err, nil)
+		}
+		pc.mainView.UpdateCommentSaved(postID, commentID)
 	})
+	pc.mainView.UpdateCommentSaved(postID, commentID)
 }
 
-func (pc *PostsController) onCloseCommentsClicked() {
-	pc.mainView.CloseComments()
+func (pc *PostsController) onSavedClicked() {
+	pc.appModel.OpenSaved()
+	pc.mainView.OpenSaved()
+	pc.retrieveMoreSaved()
+}
+
+func (pc *PostsController) onSavedBottomReached() {
+	pc.retrieveMoreSaved()
+}
+
+func (pc *PostsController) onCommentSubmitted(postID int64, parentID int64, text string) {
+	pc.mainView.SetComposerBusy(postID, parentID, true)
+	pc.appModel.CreateComment(postID, parentID, text, func(commentID int64, err error) {
+		if err != nil {
+			showError(pc.mainView, "send the comment", err, nil)
+			pc.mainView.SetComposerBusy(postID, parentID, false)
+			return
+		}
+		pc.mainView.AddComment(postID, commentID)
+	})
+}
+
+func (pc *PostsController) onCommentEdited(postID int64, commentID int64, text string) {
+	pc.mainView.SetComposerBusy(postID, commentID, true)
+	pc.appModel.EditComment(postID, commentID, text, func(err error) {
+		if err != nil {
+			showError(pc.mainView, "edit the comment", err, nil)
+			pc.mainView.SetComposerBusy(postID, commentID, false)
+			return
+		}
+		pc.mainView.UpdateComment(postID, commentID)
+	})
+}
+
+func (pc *PostsController) onCommentDeleted(postID int64, commentID int64) {
+	pc.appModel.DeleteComment(postID, commentID, func(err error) {
+		if err != nil {
+			showError(pc.mainView, "delete the comment", err, nil)
+			return
+		}
+		pc.mainView.UpdateComment(postID, commentID)
+	})
 }
 
 func (pc *PostsController) onOrderChanged(newOrder int) {
 	pc.appModel.Configuration.SetOrder(model.PostsOrder(newOrder))
 	pc.mainView.CleanView()
 	pc.appModel.CleanModel()
-	pc.appModel.RetrieveMorePosts(func(err error) {
-		if err != nil {
-			log.Println(err)
-		}
-	})
+	pc.retrieveMorePosts()
 }
 
 func (pc *PostsController) onFilterChanged(newFilter int) {
 	pc.appModel.Configuration.SetFilter(model.PostsFilter(newFilter))
 	pc.mainView.CleanView()
-	pc.appModel.CleanModel()
+	pc.mainView.SetFeedSubtitle("")
+	pc.appModel.SetFeedCommunity(0)
+	pc.retrieveMorePosts()
+}
+
+func (pc *PostsController) retrieveMorePosts() {
 	pc.appModel.RetrieveMorePosts(func(err error) {
 		if err != nil {
-			log.Println(err)
+			showError(pc.mainView, "load posts", err, pc.retrieveMorePosts)
+		}
+	})
+}
+
+func (pc *PostsController) retrieveMoreSaved() {
+	pc.appModel.RetrieveMoreSavedContent(func(err error) {
+		if err != nil {
+			showError(pc.mainView, "load the saved posts", err, pc.retrieveMoreSaved)
 		}
 	})
 }
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/view"
+)
+
+type ProfileController struct {
+	mainView *view.MainView
+	appModel *model.AppModel
+}
+
+func (pc *ProfileController) Init(mv *view.MainView, am *model.AppModel) {
+	pc.mainView = mv
+	pc.appModel = am
+
+	mv.UserClicked = pc.onUserClicked
+	mv.ProfileBottomReached = pc.onProfileBottomReached
+}
+
+func (pc *ProfileController) onUserClicked(personID int64) {
+	pc.appModel.OpenProfile(personID)
+	pc.mainView.OpenProfile()
+	pc.retrieveMoreContent()
+}
+
+func (pc *ProfileController) onProfileBottomReached() {
+	pc.retrieveMoreContent()
+}
+
+func (pc *ProfileController) retrieveMoreContent() {
+	pc.appModel.RetrieveMoreProfileContent(func(err error) {
+		if err != nil {
+			showError(pc.mainView, "load the profile", err, pc.retrieveMoreContent)
+		}
+	})
+}
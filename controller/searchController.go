@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/view"
+)
+
+type SearchController struct {
+	mainView *view.MainView
+	appModel *model.AppModel
+}
+
+func (sc *SearchController) Init(mv *view.MainView, am *model.AppModel) {
+	sc.mainView = mv
+	sc.appModel = am
+
+	mv.SearchActivated = sc.onSearchActivated
+	mv.SearchBottomReached = sc.onSearchBottomReached
+	mv.CommentContextClicked = sc.onCommentContextClicked
+}
+
+func (sc *SearchController) onSearchActivated(query string) {
+	sc.appModel.StartSearch(query)
+	sc.mainView.OpenSearch(query)
+	sc.retrieveMoreResults()
+}
+
+func (sc *SearchController) onSearchBottomReached() {
+	sc.retrieveMoreResults()
+}
+
+func (sc *SearchController) onCommentContextClicked(postID int64) {
+	sc.appModel.RetrievePost(postID, func(err error) {
+		if err != nil {
+			showError(sc.mainView, "load the post", err, func() { sc.onCommentContextClicked(postID) })
+			return
+		}
+
+		sc.appModel.RetrieveComments(postID, func(err error) {
+			if err != nil {
+				showError(sc.mainView, "load the comments", err, func() { sc.onCommentContextClicked(postID) })
+				return
+			}
+			sc.mainView.OpenComments(postID)
+		})
+	})
+}
+
+func (sc *SearchController) retrieveMoreResults() {
+	sc.appModel.RetrieveMoreSearchResults(func(err error) {
+		if err != nil {
+			showError(sc.mainView, "search", err, sc.retrieveMoreResults)
+			return
+		}
+		sc.mainView.SearchFinished()
+	})
+}
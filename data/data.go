@@ -16,3 +16,54 @@ var CommentUI []byte
 
 //go:embed style.css
 var StyleCSS []byte
+
+//go:embed commentComposer.glade
+var CommentComposerUI []byte
+
+//go:embed createPost.glade
+var CreatePostUI []byte
+
+//go:embed search.glade
+var SearchUI []byte
+
+//go:embed page.glade
+var PageUI []byte
+
+//go:embed community.glade
+var CommunityUI []byte
+
+//go:embed communityList.glade
+var CommunityListUI []byte
+
+//go:embed profile.glade
+var ProfileUI []byte
+
+//go:embed inbox.glade
+var InboxUI []byte
+
+//go:embed inboxItem.glade
+var InboxItemUI []byte
+
+//go:embed conversations.glade
+var ConversationsUI []byte
+
+//go:embed conversation.glade
+var ConversationUI []byte
+
+//go:embed message.glade
+var MessageUI []byte
+
+//go:embed saved.glade
+var SavedUI []byte
+
+//go:embed register.glade
+var RegisterUI []byte
+
+//go:embed video.glade
+var VideoUI []byte
+
+//go:embed imageViewer.glade
+var ImageViewerUI []byte
+
+//go:embed linkPreview.glade
+var LinkPreviewUI []byte
@@ -3,8 +3,11 @@ module github.com/mjdiliscia/LemmeRead
 go 1.21
 
 require (
+	github.com/godbus/dbus/v5 v5.1.0
 	github.com/gotk3/gotk3 v0.6.3
+	github.com/yuin/goldmark v1.7.8
 	go.elara.ws/go-lemmy v0.19.0
+	golang.org/x/net v0.35.0
 )
 
 require (
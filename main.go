@@ -8,7 +8,7 @@ import (
 func main() {
 	app, err := NewApplication()
 	if err != nil {
-		log.Panic(err)
+		log.Fatal(err)
 	}
 
 	app.GtkApplication.Run(os.Args)
@@ -2,41 +2,100 @@ package model
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
-	"slices"
+	"path"
 
 	"github.com/gotk3/gotk3/glib"
+	"github.com/mjdiliscia/LemmeRead/utils"
 	"go.elara.ws/go-lemmy"
 )
 
-const MAX_COMMENTS_PER_REQUEST int64 = 40
+const (
+	MAX_COMMENTS_PER_REQUEST   int64 = 40
+	MAX_COMMUNITIES_PER_SEARCH int64 = 20
+	MAX_RESULTS_PER_SEARCH     int64 = 20
+	MAX_COMMUNITIES_PER_PAGE   int64 = 20
+	MAX_PROFILE_ITEMS_PER_PAGE int64 = 20
+	MAX_SAVED_ITEMS_PER_PAGE   int64 = 20
+	MAX_INBOX_ITEMS_PER_PAGE   int64 = 20
+	MAX_MESSAGES_PER_PAGE      int64 = 50
+)
+
+// ErrAccountSwitched is returned for requests made for an account that's no
+// longer the current one.
+var ErrAccountSwitched = errors.New("account switched")
 
 type AppModel struct {
+	PostFeed
 	KnownPosts    map[int64]PostModel
-	NewPosts      func()
+	Search        SearchModel
+	Communities   CommunityListModel
+	CommunityPage CommunityPageModel
+	Profile       ProfileModel
+	Saved         SavedModel
+	Inbox         InboxModel
+	Messages      MessagesModel
 	Configuration AppModelConfiguration
+	MyUserID      int64
+	// SessionExpired is called when the server rejects the token of the current account.
+	SessionExpired func()
 
-	lastAddedPosts     []int64
-	nextPageToRetrieve int64
-	pendingProcesses   []string
 	lemmyClient        *lemmy.Client
 	lemmyContext       context.Context
+	cancelLemmyContext context.CancelFunc
+	inboxPolling       glib.SourceHandle
+	loadingMedia       map[int64]bool
+	// postFeeds tells which feed listed each post last, its media loads being
+	// cancelled along with that feed.
+	postFeeds map[int64]*PostFeed
 }
 
 func (am *AppModel) Init() {
 	am.Configuration = NewAppModelConfiguration("config.json")
-	am.CleanModel()
+	am.resetSession()
+
+	err := utils.SetupHTTPClient(am.Configuration.GetProxy())
+	if err != nil {
+		log.Printf("%s, using the environment's one instead.", err)
+	}
+
+	cacheDir := path.Join(glib.GetUserCacheDir(), configDirName, imageCacheDirName)
+	err = utils.SetupImageCache(cacheDir, am.Configuration.GetImageCacheSize())
+	if err != nil {
+		log.Printf("Couldn't setup the image cache at '%s', images will only be cached in memory: %s", cacheDir, err)
+	}
+}
+
+func (am *AppModel) SetImageCacheSize(size int64) {
+	am.Configuration.SetImageCacheSize(size)
+	utils.SetImageCacheLimit(am.Configuration.GetImageCacheSize())
+}
+
+func (am *AppModel) ImageCacheSize() int64 {
+	return utils.ImageCacheSize()
+}
+
+func (am *AppModel) ClearImageCache() error {
+	return utils.ClearImageCache()
+}
+
+func (am *AppModel) SwitchAccount(index int) error {
+	am.resetSession()
+	am.Configuration.SetCurrentAccount(index)
+	return am.InitializeLemmyClient()
 }
 
 func (am *AppModel) InitializeLemmyClient() error {
 	var err error
-	am.lemmyClient, err = lemmy.New(am.Configuration.GetLemmyServer())
+	am.lemmyClient, err = newLemmyClient(am.Configuration.GetLemmyServer())
 	if err != nil {
 		return fmt.Errorf("Couldn't create a Lemmy Client: %s", err)
 	}
 
-	am.lemmyContext = context.Background()
+	am.newLemmyContext()
 
 	log.Println("Initializing LemmyClient with existing token.")
 	am.lemmyClient.Token = am.Configuration.GetLemmyToken()
@@ -44,38 +103,191 @@ func (am *AppModel) InitializeLemmyClient() error {
 	return err
 }
 
-func (am *AppModel) InitializeLemmyClientWithLogin(url string, username string, password string, callback func(error)) {
-	var err error
-	am.lemmyClient, err = lemmy.New(url)
+// InitializeLemmyClientWithLogin logs in with a fresh client and only replaces
+// the current session once the server hands out a token, so a failed attempt
+// (wrong password, missing TOTP code...) leaves the active account untouched.
+// totp may be empty when the account has no two-factor authentication.
+func (am *AppModel) InitializeLemmyClientWithLogin(url string, username string, password string, totp string, callback func(error)) {
+	url, err := NormalizeInstanceURL(url)
+	if err != nil {
+		callback(err)
+		return
+	}
+
+	client, err := newLemmyClient(url)
 	if err != nil {
 		callback(fmt.Errorf("Couldn't create a Lemmy Client: %s", err))
+		return
 	}
 
-	am.lemmyContext = context.Background()
+	totpToken := lemmy.NewOptionalNil[string]()
+	if totp != "" {
+		totpToken = lemmy.NewOptional(totp)
+	}
 
 	go func() {
 		log.Println("Initializing LemmyClient with through login.")
-		err = am.lemmyClient.ClientLogin(am.lemmyContext, lemmy.Login{
+		response, err := client.Login(context.Background(), lemmy.Login{
 			UsernameOrEmail: username,
 			Password:        password,
-			TOTP2FAToken:    lemmy.NewOptionalNil[string](),
+			TOTP2FAToken:    totpToken,
 		})
 
-		callInMain(func() error {
-			if err == nil {
-				am.Configuration.SetLemmyServer(url)
-				am.Configuration.SetLemmyToken(am.lemmyClient.Token)
+		am.callInMain(func() error {
+			if err != nil {
+				return loginError(err)
+			}
+			return am.startSession(client, url, username, response)
+		}, callback)
+	}()
+}
+
+// InitializeAnonymousClient starts browsing url without an account. Anything
+// that needs one (voting, commenting, the inbox...) stays unavailable until the
+// user logs in.
+func (am *AppModel) InitializeAnonymousClient(url string) error {
+	url, err := NormalizeInstanceURL(url)
+	if err != nil {
+		return err
+	}
+
+	client, err := newLemmyClient(url)
+	if err != nil {
+		return fmt.Errorf("Couldn't create a Lemmy Client: %s", err)
+	}
+
+	log.Printf("Initializing LemmyClient to browse %s anonymously.", url)
+	am.resetSession()
+	am.lemmyClient = client
+	am.newLemmyContext()
+	am.Configuration.AddAnonymousAccount(url)
+	return nil
+}
+
+func (am *AppModel) DiscoverInstance(url string, callback func(InstanceModel, error)) {
+	url, err := NormalizeInstanceURL(url)
+	if err != nil {
+		callback(InstanceModel{}, err)
+		return
+	}
+
+	client, err := newLemmyClient(url)
+	if err != nil {
+		callback(InstanceModel{}, fmt.Errorf("Couldn't create a Lemmy Client: %s", err))
+		return
+	}
+
+	go func() {
+		var instance InstanceModel
+		response, err := client.Site(context.Background())
+		if err == nil {
+			instance = newInstanceModel(url, response)
+			if icon, ok := response.SiteView.Site.Icon.Value(); ok {
+				instance.Icon, err = utils.LoadPixmapFromUrl(context.Background(), icon)
+				if err != nil {
+					log.Printf("Couldn't load icon of %s: %s", url, err)
+					err = nil
+				}
+			}
+		}
+		log.Printf("Discovery of %s completed. Error: %v", url, err)
+
+		am.callInMain(func() error {
+			if err != nil {
+				return fmt.Errorf("%s doesn't look like a Lemmy instance: %s", url, err)
 			}
+			return nil
+		}, func(err error) {
+			callback(instance, err)
+		})
+	}()
+}
+
+func (am *AppModel) RetrieveCaptcha(url string, callback func(CaptchaModel, error)) {
+	client, err := newLemmyClient(url)
+	if err != nil {
+		callback(CaptchaModel{}, fmt.Errorf("Couldn't create a Lemmy Client: %s", err))
+		return
+	}
+
+	go func() {
+		var captcha CaptchaModel
+		response, err := client.Captcha(context.Background())
+		if err == nil {
+			err = fillCaptcha(&captcha, response)
+		}
+
+		am.callInMain(func() error {
 			return err
+		}, func(err error) {
+			callback(captcha, err)
+		})
+	}()
+}
+
+// RegisterAccount signs up in the instance at url. When the instance hands out
+// a token right away the new account becomes the current one, otherwise the
+// returned error tells whether email verification or admin approval is pending.
+func (am *AppModel) RegisterAccount(url string, registration Registration, callback func(error)) {
+	client, err := newLemmyClient(url)
+	if err != nil {
+		callback(fmt.Errorf("Couldn't create a Lemmy Client: %s", err))
+		return
+	}
+
+	register := lemmy.Register{
+		Username:       registration.Username,
+		Password:       registration.Password,
+		PasswordVerify: registration.PasswordVerify,
+		ShowNSFW:       registration.ShowNSFW,
+	}
+	if registration.Email != "" {
+		register.Email = lemmy.NewOptional(registration.Email)
+	}
+	if registration.Answer != "" {
+		register.Answer = lemmy.NewOptional(registration.Answer)
+	}
+	if registration.CaptchaUUID != "" {
+		register.CaptchaUUID = lemmy.NewOptional(registration.CaptchaUUID)
+		register.CaptchaAnswer = lemmy.NewOptional(registration.CaptchaAnswer)
+	}
+
+	go func() {
+		log.Printf("Registering %s at %s...", registration.Username, url)
+		response, err := client.Register(context.Background(), register)
+		am.callInMain(func() error {
+			if err != nil {
+				return loginError(err)
+			}
+			return am.startSession(client, url, registration.Username, response)
+		}, callback)
+	}()
+}
+
+func (am *AppModel) RetrieveMyUser(callback func(error)) {
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.Site(ctx)
+		am.callInMain(func() error {
+			if client != am.lemmyClient {
+				return fmt.Errorf("%w while retrieving user, ignoring.", ErrAccountSwitched)
+			}
+			if err != nil {
+				return err
+			}
+			if myUser, ok := response.MyUser.Value(); ok {
+				am.MyUserID = myUser.LocalUserView.Person.ID
+				am.Configuration.SetUsername(myUser.LocalUserView.Person.Name)
+			}
+			return nil
 		}, callback)
 	}()
 }
 
 func (am *AppModel) CleanModel() {
-	am.nextPageToRetrieve = 0
 	am.KnownPosts = make(map[int64]PostModel)
-	am.lastAddedPosts = make([]int64, 0)
-	am.pendingProcesses = make([]string, 0)
+	am.postFeeds = make(map[int64]*PostFeed)
+	am.CleanFeed()
 }
 
 func (am *AppModel) RetrieveMorePosts(callback func(error)) {
@@ -88,68 +300,160 @@ func (am *AppModel) RetrieveMorePosts(callback func(error)) {
 }
 
 func (am *AppModel) RetrievePosts(page int64, callback func(error)) {
-	if len(am.pendingProcesses) > 0 {
-		callback(fmt.Errorf("Already retrieving posts, ignoring."))
-		return
-	}
+	am.retrieveFeedPosts(&am.PostFeed, page, callback)
+}
 
-	log.Printf("Retrieving posts from page %d...", page)
+func (am *AppModel) SetFeedCommunity(communityID int64) {
+	am.CleanModel()
+	am.CommunityID = communityID
+}
 
-	processID := fmt.Sprintf("list%d", page)
-	am.pendingProcesses = append(am.pendingProcesses, processID)
+func (am *AppModel) OpenCommunityPage(communityID int64) {
+	am.CommunityPage.CleanPage(communityID)
+}
+
+func (am *AppModel) RetrieveCommunity(communityID int64, callback func(error)) {
+	client, ctx := am.lemmyClient, am.lemmyContext
 	go func() {
-		response, err := am.lemmyClient.Posts(am.lemmyContext, lemmy.GetPosts{
-			Type: lemmy.NewOptional(am.getCurrentType()),
-			Page: lemmy.NewOptional(page + 1),
-			Sort: lemmy.NewOptional(am.getCurrentSort()),
+		response, err := client.Community(ctx, lemmy.GetCommunity{
+			ID: lemmy.NewOptional(communityID),
 		})
-		log.Printf("Posts from page %d retrieval completed. Error: %v", page, err)
-		callInMain(func() error {
-			if slices.Index(am.pendingProcesses, processID) == -1 {
-				return fmt.Errorf("Process %s no longer needed", processID)
-			}
-
-			return am.addPosts(response.Posts, err)
-		}, func(err error) {
-			processIndex := slices.Index(am.pendingProcesses, processID)
-			if processIndex != -1 {
-				am.pendingProcesses = append(am.pendingProcesses[:processIndex], am.pendingProcesses[processIndex+1:]...)
+		log.Printf("Community %d retrieval completed. Error: %v", communityID, err)
+		am.callInMain(func() error { return err }, func(err error) {
+			if err != nil {
+				callback(err)
+				return
 			}
 
-			callback(err)
+			communityModel := &CommunityModel{CommunityView: response.CommunityView}
+			communityModel.Init(ctx, func(err error) {
+				if err == nil && am.CommunityPage.CommunityID == communityID {
+					am.CommunityPage.Community = *communityModel
+				}
+				callback(err)
+			})
 		})
 	}()
 }
 
+func (am *AppModel) RetrieveMoreCommunityPosts(callback func(error)) {
+	feed := &am.CommunityPage.PostFeed
+	communityID := feed.CommunityID
+	am.retrieveFeedPosts(feed, feed.nextPageToRetrieve, func(err error) {
+		if err == nil && feed.CommunityID == communityID {
+			feed.nextPageToRetrieve++
+		}
+		callback(err)
+	})
+}
+
 func (am *AppModel) RetrievePost(postId int64, callback func(error)) {
+	if _, ok := am.KnownPosts[postId]; ok {
+		callback(nil)
+		return
+	}
+
+	client, ctx := am.lemmyClient, am.lemmyContext
 	go func() {
-		response, err := am.lemmyClient.Post(am.lemmyContext, lemmy.GetPost{
+		response, err := client.Post(ctx, lemmy.GetPost{
 			ID: lemmy.NewOptional(postId),
 		})
-		callInMain(func() error { return am.addPosts([]lemmy.PostView{response.PostView}, err) }, callback)
+		am.callInMain(func() error { return err }, func(err error) {
+			if err != nil {
+				callback(err)
+				return
+			}
+
+			am.KnownPosts[postId] = NewPostModel(response.PostView)
+			callback(nil)
+		})
+	}()
+}
+
+func (am *AppModel) RetrieveMoreCommunities(callback func(error)) {
+	if am.Communities.retrieving {
+		callback(fmt.Errorf("Already retrieving communities, ignoring."))
+		return
+	}
+
+	page := am.Communities.nextPageToRetrieve
+	processID := am.Communities.processID
+	am.Communities.retrieving = true
+	log.Printf("Retrieving communities from page %d...", page)
+
+	listCommunities := lemmy.ListCommunities{
+		Type:  lemmy.NewOptional(am.Communities.getType()),
+		Sort:  lemmy.NewOptional(am.Communities.getSort()),
+		Page:  lemmy.NewOptional(page + 1),
+		Limit: lemmy.NewOptional(MAX_COMMUNITIES_PER_PAGE),
+	}
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.Communities(ctx, listCommunities)
+		log.Printf("Communities from page %d retrieval completed. Error: %v", page, err)
+		am.callInMain(func() error {
+			if processID != am.Communities.processID {
+				return fmt.Errorf("Communities page %d no longer needed", page)
+			}
+			if err != nil {
+				am.Communities.retrieving = false
+				return err
+			}
+
+			am.Communities.nextPageToRetrieve++
+			am.addCommunities(processID, response.Communities)
+			return nil
+		}, callback)
+	}()
+}
+
+func (am *AppModel) FollowCommunity(communityID int64, follow bool, callback func(error)) {
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.FollowCommunity(ctx, lemmy.FollowCommunity{
+			CommunityID: communityID,
+			Follow:      follow,
+		})
+		log.Printf("Follow %t of community %d completed. Error: %v", follow, communityID, err)
+		am.callInMain(func() error {
+			if err != nil {
+				return err
+			}
+
+			if community, ok := am.Communities.KnownCommunities[communityID]; ok {
+				community.CommunityView = response.CommunityView
+				am.Communities.KnownCommunities[communityID] = community
+			}
+			if am.CommunityPage.Community.Community.ID == communityID {
+				am.CommunityPage.Community.CommunityView = response.CommunityView
+			}
+			return nil
+		}, callback)
 	}()
 }
 
 func (am *AppModel) RetrieveComments(postID int64, callback func(error)) {
+	commentCount := am.KnownPosts[postID].Counts.Comments
+	client, ctx := am.lemmyClient, am.lemmyContext
 	go func() {
-		remainingPages := 1 + am.KnownPosts[postID].Counts.Comments/MAX_COMMENTS_PER_REQUEST
-		collectedComments := make([]lemmy.CommentView, 0, am.KnownPosts[postID].Counts.Comments)
+		remainingPages := 1 + commentCount/MAX_COMMENTS_PER_REQUEST
+		collectedComments := make([]lemmy.CommentView, 0, commentCount)
 
 		for ; remainingPages > 0; remainingPages-- {
 			log.Printf("Asking for comments page %d", remainingPages)
-			response, err := am.lemmyClient.Comments(am.lemmyContext, lemmy.GetComments{
-				PostID: lemmy.NewOptional(am.KnownPosts[postID].Post.ID),
+			response, err := client.Comments(ctx, lemmy.GetComments{
+				PostID: lemmy.NewOptional(postID),
 				Limit:  lemmy.NewOptional(MAX_COMMENTS_PER_REQUEST),
 				Page:   lemmy.NewOptional(remainingPages),
 			})
 			if err != nil {
-				callInMain(func() error { return err }, callback)
+				am.callInMain(func() error { return err }, callback)
 				return
 			}
 			collectedComments = append(collectedComments, response.Comments...)
 		}
 
-		callInMain(func() error {
+		am.callInMain(func() error {
 			if post, ok := am.KnownPosts[postID]; ok {
 				err := post.AddComments(collectedComments, nil)
 				am.KnownPosts[postID] = post
@@ -168,117 +472,1102 @@ func (am *AppModel) RetrieveComments(postID int64, callback func(error)) {
 	}()
 }
 
-func (am *AppModel) ConsumeLastAddedPosts() []int64 {
-	var (
-		beginReady int = -1
-		endReady   int = -1
-	)
+func (am *AppModel) VotePost(postID int64, score int64, callback func(error)) {
+	post, ok := am.KnownPosts[postID]
+	if !ok {
+		callback(fmt.Errorf("Post %d couldn't be found in local DB", postID))
+		return
+	}
+
+	previousView := post.PostView
+	post.SetMyVote(score)
+	am.KnownPosts[postID] = post
 
-	for idx, postId := range am.lastAddedPosts {
-		if postId == 0 && beginReady == -1 {
-			return make([]int64, 0)
-		}
-		if postId > 0 && beginReady == -1 {
-			beginReady = idx
-		}
-		if postId == 0 && endReady == -1 {
-			endReady = idx
-		}
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.LikePost(ctx, lemmy.CreatePostLike{
+			PostID: postID,
+			Score:  score,
+		})
+		log.Printf("Vote %d on post %d completed. Error: %v", score, postID, err)
+		am.callInMain(func() error {
+			post, ok := am.KnownPosts[postID]
+			if !ok {
+				return fmt.Errorf("Post %d no longer in local DB", postID)
+			}
+
+			if err != nil {
+				post.PostView = previousView
+			} else {
+				post.PostView = response.PostView
+			}
+			am.KnownPosts[postID] = post
+			return err
+		}, callback)
+	}()
+}
+
+func (am *AppModel) VoteComment(postID int64, commentID int64, score int64, callback func(error)) {
+	post, ok := am.KnownPosts[postID]
+	if !ok {
+		callback(fmt.Errorf("Post %d couldn't be found in local DB", postID))
+		return
 	}
 
-	if endReady == -1 {
-		endReady = len(am.lastAddedPosts)
+	comment := post.FindComment(commentID)
+	if comment == nil {
+		callback(fmt.Errorf("Comment %d couldn't be found in post %d", commentID, postID))
+		return
 	}
 
-	defer func() {
-		for idx := beginReady; idx < endReady; idx++ {
-			am.lastAddedPosts[idx] = -1
-		}
-	}()
+	previousView := comment.CommentView
+	comment.SetMyVote(score)
 
-	response := append(make([]int64, 0), am.lastAddedPosts[beginReady:endReady]...)
-	return response
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.LikeComment(ctx, lemmy.CreateCommentLike{
+			CommentID: commentID,
+			Score:     score,
+		})
+		log.Printf("Vote %d on comment %d completed. Error: %v", score, commentID, err)
+		am.callInMain(func() error {
+			if err != nil {
+				comment.CommentView = previousView
+			} else {
+				comment.CommentView = response.CommentView
+			}
+			return err
+		}, callback)
+	}()
 }
 
-func (am *AppModel) addPosts(posts []lemmy.PostView, err error) error {
-	if err != nil {
-		log.Println("addPost called with errors, ignoring call.")
-		return err
+func (am *AppModel) SavePost(postID int64, save bool, callback func(error)) {
+	post, ok := am.KnownPosts[postID]
+	if !ok {
+		callback(fmt.Errorf("Post %d couldn't be found in local DB", postID))
+		return
 	}
 
-	log.Printf("Adding %d new posts to local DB.", len(posts))
-	am.lastAddedPosts = make([]int64, len(posts))
-	for idx, post := range posts {
-		if _, ok := am.KnownPosts[post.Post.ID]; !ok {
-			postModel := PostModel{PostView: post}
-			postID := post.Post.ID
-			postIdx := idx
-
-			processID := fmt.Sprintf("post%d", postID)
-			am.pendingProcesses = append(am.pendingProcesses, processID)
-			postModel.Init(func(err error) {
-				processIndex := slices.Index(am.pendingProcesses, processID)
-				if processIndex == -1 {
-					log.Printf("Process for post %d not needed anymore, skipping: %s", postID, err)
-					return
-				}
-				am.pendingProcesses = append(am.pendingProcesses[:processIndex], am.pendingProcesses[processIndex+1:]...)
+	previousView := post.PostView
+	post.Saved = save
+	am.KnownPosts[postID] = post
 
-				if err != nil {
-					log.Printf("Something went wrong with post %d, skipping: %s", postID, err)
-					return
-				}
-				am.KnownPosts[postID] = postModel
-				am.lastAddedPosts[postIdx] = postID
-				log.Printf("Added new post %d to %p DB with %d posts.", postID, &am.KnownPosts, len(am.KnownPosts))
-				if am.NewPosts != nil {
-					am.NewPosts()
-				}
-			})
-		}
-	}
-	return err
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.SavePost(ctx, lemmy.SavePost{
+			PostID: postID,
+			Save:   save,
+		})
+		log.Printf("Save %t on post %d completed. Error: %v", save, postID, err)
+		am.callInMain(func() error {
+			post, ok := am.KnownPosts[postID]
+			if !ok {
+				return fmt.Errorf("Post %d no longer in local DB", postID)
+			}
+
+			if err != nil {
+				post.PostView = previousView
+			} else {
+				post.PostView = response.PostView
+			}
+			am.KnownPosts[postID] = post
+			return err
+		}, callback)
+	}()
 }
 
-func (am *AppModel) getCurrentSort() lemmy.SortType {
-	order := am.Configuration.GetOrder()
-	switch order {
-	case PostOrderActive:
-		return lemmy.SortTypeActive
-	case PostOrderHot:
-		return lemmy.SortTypeHot
-	case PostOrderScaled:
-		return lemmy.SortTypeScaled
-	case PostOrderControversial:
-		return lemmy.SortTypeControversial
-	case PostOrderNew:
-		return lemmy.SortTypeNew
-	case PostOrderOld:
-		return lemmy.SortTypeOld
-	case PostOderMostComments:
-		return lemmy.SortTypeMostComments
-	case PostOrderNewComments:
-		return lemmy.SortTypeNewComments
+func (am *AppModel) SaveComment(postID int64, commentID int64, save bool, callback func(error)) {
+	post, ok := am.KnownPosts[postID]
+	if !ok {
+		callback(fmt.Errorf("Post %d couldn't be found in local DB", postID))
+		return
 	}
-	return lemmy.SortTypeActive
-}
 
-func (am *AppModel) getCurrentType() lemmy.ListingType {
-	filter := am.Configuration.GetFilter()
-	switch filter {
-	case PostFilterSubscribed:
-		return lemmy.ListingTypeSubscribed
-	case PostFilterLocal:
-		return lemmy.ListingTypeLocal
-	case PostFilterAll:
-		return lemmy.ListingTypeAll
+	comment := post.FindComment(commentID)
+	if comment == nil {
+		callback(fmt.Errorf("Comment %d couldn't be found in post %d", commentID, postID))
+		return
 	}
-	return lemmy.ListingTypeSubscribed
+
+	previousView := comment.CommentView
+	comment.Saved = save
+
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.SaveComment(ctx, lemmy.SaveComment{
+			CommentID: commentID,
+			Save:      save,
+		})
+		log.Printf("Save %t on comment %d completed. Error: %v", save, commentID, err)
+		am.callInMain(func() error {
+			if err != nil {
+				comment.CommentView = previousView
+			} else {
+				comment.CommentView = response.CommentView
+			}
+			return err
+		}, callback)
+	}()
 }
 
-func callInMain(function func() error, callback func(error)) {
-	glib.IdleAdd(func() bool {
-		err := function()
+func (am *AppModel) CreateComment(postID int64, parentID int64, content string, callback func(int64, error)) {
+	createComment := lemmy.CreateComment{
+		PostID:  postID,
+		Content: content,
+	}
+	if parentID != 0 {
+		createComment.ParentID = lemmy.NewOptional(parentID)
+	}
+
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.CreateComment(ctx, createComment)
+		log.Printf("Comment creation on post %d completed. Error: %v", postID, err)
+
+		var commentID int64
+		am.callInMain(func() error {
+			if err != nil {
+				return err
+			}
+
+			post, ok := am.KnownPosts[postID]
+			if !ok {
+				return fmt.Errorf("Post %d no longer in local DB", postID)
+			}
+
+			err := post.AddComments([]lemmy.CommentView{response.CommentView}, nil)
+			if err == nil {
+				post.Counts.Comments++
+				commentID = response.CommentView.Comment.ID
+			}
+			am.KnownPosts[postID] = post
+			return err
+		}, func(err error) {
+			callback(commentID, err)
+		})
+	}()
+}
+
+func (am *AppModel) EditComment(postID int64, commentID int64, content string, callback func(error)) {
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.EditComment(ctx, lemmy.EditComment{
+			CommentID: commentID,
+			Content:   lemmy.NewOptional(content),
+		})
+		log.Printf("Edition of comment %d completed. Error: %v", commentID, err)
+		am.callInMain(func() error {
+			if err != nil {
+				return err
+			}
+			return am.updateComment(postID, response.CommentView)
+		}, callback)
+	}()
+}
+
+func (am *AppModel) DeleteComment(postID int64, commentID int64, callback func(error)) {
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.DeleteComment(ctx, lemmy.DeleteComment{
+			CommentID: commentID,
+			Deleted:   true,
+		})
+		log.Printf("Deletion of comment %d completed. Error: %v", commentID, err)
+		am.callInMain(func() error {
+			if err != nil {
+				return err
+			}
+			return am.updateComment(postID, response.CommentView)
+		}, callback)
+	}()
+}
+
+func (am *AppModel) StartSearch(query string) {
+	am.Search.CleanSearch(query)
+}
+
+func (am *AppModel) RetrieveMoreSearchResults(callback func(error)) {
+	if am.Search.isRetrieving() {
+		callback(fmt.Errorf("Already retrieving search results, ignoring."))
+		return
+	}
+	if am.Search.exhausted {
+		callback(nil)
+		return
+	}
+
+	query := am.Search.Query
+	page := am.Search.nextPageToRetrieve
+	log.Printf("Retrieving search results for '%s' from page %d...", query, page)
+
+	processID := fmt.Sprintf("search%s%d", query, page)
+	am.Search.startProcess(processID)
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.Search(ctx, lemmy.Search{
+			Q:           query,
+			Type:        lemmy.NewOptional(lemmy.SearchTypeAll),
+			ListingType: lemmy.NewOptional(lemmy.ListingTypeAll),
+			Sort:        lemmy.NewOptional(lemmy.SortTypeTopAll),
+			Page:        lemmy.NewOptional(page + 1),
+			Limit:       lemmy.NewOptional(MAX_RESULTS_PER_SEARCH),
+		})
+		log.Printf("Search results from page %d retrieval completed. Error: %v", page, err)
+		am.callInMain(func() error {
+			if !am.Search.isProcessPending(processID) {
+				return fmt.Errorf("Process %s no longer needed", processID)
+			}
+			if err != nil {
+				return err
+			}
+
+			am.Search.nextPageToRetrieve++
+			am.Search.exhausted = len(response.Posts)+len(response.Comments)+len(response.Communities)+len(response.Users) == 0
+			for _, community := range response.Communities {
+				am.Search.lastAddedCommunities = append(am.Search.lastAddedCommunities, CommunityModel{CommunityView: community})
+			}
+			for _, user := range response.Users {
+				am.Search.lastAddedUsers = append(am.Search.lastAddedUsers, PersonModel{PersonView: user})
+			}
+			for _, comment := range response.Comments {
+				am.Search.lastAddedComments = append(am.Search.lastAddedComments, CommentModel{CommentView: comment})
+			}
+			am.Search.signalNewResults()
+
+			return am.addPosts(&am.Search.PostFeed, response.Posts, false, nil)
+		}, func(err error) {
+			am.Search.endProcess(processID)
+			callback(err)
+		})
+	}()
+}
+
+func (am *AppModel) OpenProfile(personID int64) {
+	am.Profile.CleanProfile(personID)
+}
+
+func (am *AppModel) RetrieveMoreProfileContent(callback func(error)) {
+	if am.Profile.isRetrieving() {
+		callback(fmt.Errorf("Already retrieving profile content, ignoring."))
+		return
+	}
+	if am.Profile.exhausted {
+		callback(nil)
+		return
+	}
+
+	personID := am.Profile.PersonID
+	page := am.Profile.nextPageToRetrieve
+	log.Printf("Retrieving profile of %d from page %d...", personID, page)
+
+	processID := fmt.Sprintf("profile%d-%d", personID, page)
+	am.Profile.startProcess(processID)
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.PersonDetails(ctx, lemmy.GetPersonDetails{
+			PersonID: lemmy.NewOptional(personID),
+			Sort:     lemmy.NewOptional(lemmy.SortTypeNew),
+			Page:     lemmy.NewOptional(page + 1),
+			Limit:    lemmy.NewOptional(MAX_PROFILE_ITEMS_PER_PAGE),
+		})
+		log.Printf("Profile of %d from page %d retrieval completed. Error: %v", personID, page, err)
+		am.callInMain(func() error {
+			if !am.Profile.isProcessPending(processID) {
+				return fmt.Errorf("Process %s no longer needed", processID)
+			}
+			if err != nil {
+				return err
+			}
+
+			if page == 0 {
+				personModel := &PersonModel{PersonView: response.PersonView}
+				personModel.Init(am.Profile.requestContext(ctx), func(err error) {
+					if am.Profile.PersonID != personID {
+						return
+					}
+					am.Profile.Person = *personModel
+					am.Profile.signalNewPerson()
+				})
+			}
+
+			am.Profile.nextPageToRetrieve++
+			am.Profile.exhausted = len(response.Posts)+len(response.Comments) == 0
+			for _, comment := range response.Comments {
+				am.Profile.lastAddedComments = append(am.Profile.lastAddedComments, CommentModel{CommentView: comment})
+			}
+			am.Profile.signalNewComments()
+
+			return am.addPosts(&am.Profile.PostFeed, response.Posts, false, nil)
+		}, func(err error) {
+			am.Profile.endProcess(processID)
+			callback(err)
+		})
+	}()
+}
+
+func (am *AppModel) OpenSaved() {
+	am.Saved.CleanSaved()
+}
+
+func (am *AppModel) RetrieveMoreSavedContent(callback func(error)) {
+	if am.Saved.isRetrieving() {
+		callback(fmt.Errorf("Already retrieving saved content, ignoring."))
+		return
+	}
+	if am.Saved.exhausted {
+		callback(nil)
+		return
+	}
+
+	page := am.Saved.nextPageToRetrieve
+	log.Printf("Retrieving saved content from page %d...", page)
+
+	processID := fmt.Sprintf("saved-%d", page)
+	am.Saved.startProcess(processID)
+	myUserID := am.MyUserID
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.PersonDetails(ctx, lemmy.GetPersonDetails{
+			PersonID:  lemmy.NewOptional(myUserID),
+			SavedOnly: lemmy.NewOptional(true),
+			Sort:      lemmy.NewOptional(lemmy.SortTypeNew),
+			Page:      lemmy.NewOptional(page + 1),
+			Limit:     lemmy.NewOptional(MAX_SAVED_ITEMS_PER_PAGE),
+		})
+		log.Printf("Saved content from page %d retrieval completed. Error: %v", page, err)
+		am.callInMain(func() error {
+			if !am.Saved.isProcessPending(processID) {
+				return fmt.Errorf("Process %s no longer needed", processID)
+			}
+			if err != nil {
+				return err
+			}
+
+			am.Saved.nextPageToRetrieve++
+			am.Saved.exhausted = len(response.Posts)+len(response.Comments) == 0
+			for _, comment := range response.Comments {
+				am.Saved.lastAddedComments = append(am.Saved.lastAddedComments, CommentModel{CommentView: comment})
+			}
+			am.Saved.signalNewComments()
+
+			return am.addPosts(&am.Saved.PostFeed, response.Posts, false, nil)
+		}, func(err error) {
+			am.Saved.endProcess(processID)
+			callback(err)
+		})
+	}()
+}
+
+func (am *AppModel) StartInboxPolling() {
+	am.StopInboxPolling()
+
+	poll := func() bool {
+		am.PollInbox(func(err error) {
+			if err != nil {
+				log.Println(err)
+			}
+		})
+		return true
+	}
+	poll()
+	am.inboxPolling = glib.TimeoutAdd(uint(am.Configuration.GetInboxPollingInterval().Milliseconds()), poll)
+}
+
+func (am *AppModel) StopInboxPolling() {
+	if am.inboxPolling != 0 {
+		glib.SourceRemove(am.inboxPolling)
+		am.inboxPolling = 0
+	}
+}
+
+func (am *AppModel) PollInbox(callback func(error)) {
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.UnreadCount(ctx)
+		am.callInMain(func() error {
+			if err != nil {
+				return err
+			}
+			am.Inbox.setUnreadCounts(response.Replies, response.Mentions, response.PrivateMessages)
+			return nil
+		}, callback)
+	}()
+}
+
+func (am *AppModel) OpenInbox(unreadOnly bool) {
+	am.Inbox.CleanInbox(unreadOnly)
+}
+
+func (am *AppModel) RetrieveMoreInboxItems(callback func(error)) {
+	if am.Inbox.retrieving {
+		callback(fmt.Errorf("Already retrieving inbox, ignoring."))
+		return
+	}
+	if am.Inbox.isExhausted() {
+		callback(nil)
+		return
+	}
+	// Received messages are told apart from sent ones by their recipient, so
+	// the user has to be known first.
+	if am.MyUserID == 0 {
+		am.RetrieveMyUser(func(err error) {
+			if err == nil && am.MyUserID == 0 {
+				err = fmt.Errorf("Not logged in, there's no inbox to retrieve.")
+			}
+			if err != nil {
+				callback(err)
+				return
+			}
+			am.RetrieveMoreInboxItems(callback)
+		})
+		return
+	}
+
+	// Replies, mentions and messages are paged separately, so only the lists
+	// that ran out of items are fetched and merged into the rest.
+	kinds := am.Inbox.sourcesToRetrieve()
+	pages := make([]int64, len(kinds))
+	for idx, kind := range kinds {
+		pages[idx] = am.Inbox.sources[kind].nextPageToRetrieve
+	}
+	processID := am.Inbox.processID
+	unreadOnly := am.Inbox.UnreadOnly
+	myUserID := am.MyUserID
+	client, ctx := am.lemmyClient, am.lemmyContext
+	am.Inbox.retrieving = true
+	log.Printf("Retrieving inbox pages %v of %v...", pages, kinds)
+
+	go func() {
+		var err error
+		results := make([][]InboxItem, len(kinds))
+		nextPages := make([]int64, len(kinds))
+		exhausted := make([]bool, len(kinds))
+		for idx, kind := range kinds {
+			results[idx], nextPages[idx], exhausted[idx], err = fetchInboxSource(client, ctx, kind, pages[idx], unreadOnly, myUserID)
+			if err != nil {
+				break
+			}
+		}
+		log.Printf("Inbox retrieval completed. Error: %v", err)
+		am.callInMain(func() error {
+			if processID != am.Inbox.processID {
+				return fmt.Errorf("Inbox pages %v no longer needed", pages)
+			}
+			am.Inbox.retrieving = false
+			if err != nil {
+				return err
+			}
+
+			for idx, kind := range kinds {
+				am.Inbox.addSourceItems(kind, results[idx], nextPages[idx], exhausted[idx])
+			}
+			am.Inbox.addItems(am.Inbox.mergeSources(int(MAX_INBOX_ITEMS_PER_PAGE)))
+			am.Inbox.signalNewItems()
+			return nil
+		}, callback)
+	}()
+}
+
+func (am *AppModel) MarkInboxItemRead(kind InboxItemKind, id int64, callback func(error)) {
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		var err error
+		switch kind {
+		case InboxItemReply:
+			_, err = client.MarkCommentReplyAsRead(ctx, lemmy.MarkCommentReplyAsRead{
+				CommentReplyID: id,
+				Read:           true,
+			})
+		case InboxItemMention:
+			_, err = client.MarkPersonMentionAsRead(ctx, lemmy.MarkPersonMentionAsRead{
+				PersonMentionID: id,
+				Read:            true,
+			})
+		case InboxItemMessage:
+			_, err = client.MarkPrivateMessageAsRead(ctx, lemmy.MarkPrivateMessageAsRead{
+				PrivateMessageID: id,
+				Read:             true,
+			})
+		}
+		log.Printf("Marking inbox item %d as read completed. Error: %v", id, err)
+		am.callInMain(func() error {
+			if err != nil {
+				return err
+			}
+
+			if item := am.Inbox.FindItem(kind, id); item != nil && !item.Read {
+				item.Read = true
+				am.Inbox.markRead(kind)
+			}
+			return nil
+		}, callback)
+	}()
+}
+
+func (am *AppModel) MarkAllInboxRead(callback func(error)) {
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		_, err := client.MarkAllAsRead(ctx)
+		log.Printf("Marking all inbox as read completed. Error: %v", err)
+		am.callInMain(func() error {
+			if err != nil {
+				return err
+			}
+
+			for idx := range am.Inbox.Items {
+				am.Inbox.Items[idx].Read = true
+			}
+			am.Inbox.setUnreadCounts(0, 0, 0)
+			return nil
+		}, callback)
+	}()
+}
+
+func (am *AppModel) OpenMessages() {
+	am.Messages.CleanMessages()
+}
+
+func (am *AppModel) RetrieveMoreMessages(callback func(error)) {
+	if am.Messages.retrieving {
+		callback(fmt.Errorf("Already retrieving messages, ignoring."))
+		return
+	}
+	if am.Messages.exhausted {
+		callback(nil)
+		return
+	}
+
+	page := am.Messages.nextPageToRetrieve
+	processID := am.Messages.processID
+	am.Messages.retrieving = true
+	log.Printf("Retrieving private messages from page %d...", page)
+
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.PrivateMessages(ctx, lemmy.GetPrivateMessages{
+			Page:  lemmy.NewOptional(page + 1),
+			Limit: lemmy.NewOptional(MAX_MESSAGES_PER_PAGE),
+		})
+		log.Printf("Private messages from page %d retrieval completed. Error: %v", page, err)
+		am.callInMain(func() error {
+			if processID != am.Messages.processID {
+				return fmt.Errorf("Messages page %d no longer needed", page)
+			}
+			am.Messages.retrieving = false
+			if err != nil {
+				return err
+			}
+
+			am.Messages.nextPageToRetrieve++
+			am.Messages.exhausted = len(response.PrivateMessages) == 0
+			for _, message := range response.PrivateMessages {
+				am.Messages.addMessage(am.MyUserID, message)
+			}
+			am.Messages.signalNewConversations()
+			return nil
+		}, callback)
+	}()
+}
+
+func (am *AppModel) OpenConversation(counterpart lemmy.Person, callback func(error)) {
+	am.Messages.EnsureConversation(counterpart)
+	if am.Messages.nextPageToRetrieve > 0 || am.Messages.retrieving {
+		callback(nil)
+		return
+	}
+
+	am.RetrieveMoreMessages(callback)
+}
+
+func (am *AppModel) SendPrivateMessage(recipientID int64, content string, callback func(error)) {
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.CreatePrivateMessage(ctx, lemmy.CreatePrivateMessage{
+			RecipientID: recipientID,
+			Content:     content,
+		})
+		log.Printf("Private message to %d completed. Error: %v", recipientID, err)
+		am.callInMain(func() error {
+			if err != nil {
+				return err
+			}
+			am.updatePrivateMessage(response.PrivateMessageView)
+			return nil
+		}, callback)
+	}()
+}
+
+func (am *AppModel) EditPrivateMessage(messageID int64, content string, callback func(error)) {
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.EditPrivateMessage(ctx, lemmy.EditPrivateMessage{
+			PrivateMessageID: messageID,
+			Content:          content,
+		})
+		log.Printf("Edition of private message %d completed. Error: %v", messageID, err)
+		am.callInMain(func() error {
+			if err != nil {
+				return err
+			}
+			am.updatePrivateMessage(response.PrivateMessageView)
+			return nil
+		}, callback)
+	}()
+}
+
+func (am *AppModel) DeletePrivateMessage(messageID int64, callback func(error)) {
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.DeletePrivateMessage(ctx, lemmy.DeletePrivateMessage{
+			PrivateMessageID: messageID,
+			Deleted:          true,
+		})
+		log.Printf("Deletion of private message %d completed. Error: %v", messageID, err)
+		am.callInMain(func() error {
+			if err != nil {
+				return err
+			}
+			am.updatePrivateMessage(response.PrivateMessageView)
+			return nil
+		}, callback)
+	}()
+}
+
+func (am *AppModel) MarkConversationRead(counterpartID int64) {
+	conversation, ok := am.Messages.Conversations[counterpartID]
+	if !ok {
+		return
+	}
+
+	unread := conversation.UnreadMessages(am.MyUserID)
+	pending := len(unread)
+	for _, unreadID := range unread {
+		messageID := unreadID
+		am.MarkInboxItemRead(InboxItemMessage, messageID, func(err error) {
+			if err != nil {
+				log.Println(err)
+			} else if message := conversation.FindMessage(messageID); message != nil {
+				message.PrivateMessage.Read = true
+			}
+
+			pending--
+			if pending == 0 {
+				am.PollInbox(func(err error) {
+					if err != nil {
+						log.Println(err)
+					}
+				})
+			}
+		})
+	}
+}
+
+func (am *AppModel) SearchCommunities(query string, callback func([]CommunityModel, error)) {
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.Search(ctx, lemmy.Search{
+			Q:     query,
+			Type:  lemmy.NewOptional(lemmy.SearchTypeCommunities),
+			Limit: lemmy.NewOptional(MAX_COMMUNITIES_PER_SEARCH),
+		})
+
+		communities := make([]CommunityModel, 0)
+		am.callInMain(func() error {
+			if err != nil {
+				return err
+			}
+			for _, community := range response.Communities {
+				communities = append(communities, CommunityModel{CommunityView: community})
+			}
+			return nil
+		}, func(err error) {
+			callback(communities, err)
+		})
+	}()
+}
+
+func (am *AppModel) UploadImage(filePath string, callback func(string, error)) {
+	server := am.Configuration.GetLemmyServer()
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		imageUrl, err := utils.UploadImage(ctx, server, client.Token, filePath)
+		log.Printf("Upload of '%s' completed. Error: %v", filePath, err)
+		am.callInMain(func() error { return err }, func(err error) {
+			callback(imageUrl, err)
+		})
+	}()
+}
+
+func (am *AppModel) CreatePost(communityID int64, title string, url string, body string, nsfw bool, callback func(error)) {
+	createPost := lemmy.CreatePost{
+		CommunityID: communityID,
+		Name:        title,
+		NSFW:        lemmy.NewOptional(nsfw),
+	}
+	if url != "" {
+		createPost.URL = lemmy.NewOptional(url)
+	}
+	if body != "" {
+		createPost.Body = lemmy.NewOptional(body)
+	}
+
+	client, ctx := am.lemmyClient, am.lemmyContext
+	go func() {
+		response, err := client.CreatePost(ctx, createPost)
+		log.Printf("Post creation on community %d completed. Error: %v", communityID, err)
+		am.callInMain(func() error {
+			if err != nil {
+				return err
+			}
+			return am.addPosts(&am.PostFeed, []lemmy.PostView{response.PostView}, true, nil)
+		}, callback)
+	}()
+}
+
+func (am *AppModel) startSession(client *lemmy.Client, url string, username string, response *lemmy.LoginResponse) error {
+	token, ok := response.JWT.Value()
+	if !ok || token == "" {
+		if response.VerifyEmailSent {
+			return ErrEmailNotVerified
+		}
+		if response.RegistrationCreated {
+			return ErrRegistrationPending
+		}
+		return lemmy.ErrNoToken
+	}
+
+	am.resetSession()
+	client.Token = token
+	am.lemmyClient = client
+	am.newLemmyContext()
+	am.Configuration.AddAccount(url, username, token)
+	return nil
+}
+
+func fillCaptcha(captcha *CaptchaModel, response *lemmy.GetCaptchaResponse) error {
+	ok, isSet := response.Ok.Value()
+	if !isSet {
+		return fmt.Errorf("The instance didn't provide a captcha")
+	}
+
+	png, err := base64.StdEncoding.DecodeString(ok.PNG)
+	if err != nil {
+		return err
+	}
+
+	captcha.UUID = ok.UUID
+	captcha.Image, err = utils.PixbufFromData(png)
+	return err
+}
+
+func newLemmyClient(url string) (*lemmy.Client, error) {
+	return lemmy.NewWithClient(url, utils.HTTPClient())
+}
+
+func (am *AppModel) newLemmyContext() {
+	if am.cancelLemmyContext != nil {
+		am.cancelLemmyContext()
+	}
+	am.lemmyContext, am.cancelLemmyContext = context.WithCancel(context.Background())
+}
+
+// resetSession drops everything tied to the current account, cancelling any
+// request still in flight so its results don't leak into the next one.
+func (am *AppModel) resetSession() {
+	am.StopInboxPolling()
+	if am.cancelLemmyContext != nil {
+		am.cancelLemmyContext()
+		am.cancelLemmyContext = nil
+	}
+	am.MyUserID = 0
+
+	am.SetFeedCommunity(0)
+	am.Search.CleanSearch("")
+	am.Communities.CleanList()
+	am.CommunityPage.CleanPage(0)
+	am.Profile.CleanProfile(0)
+	am.Saved.CleanSaved()
+	am.Inbox.CleanInbox(false)
+	am.Inbox.setUnreadCounts(0, 0, 0)
+	am.Messages.CleanMessages()
+}
+
+func (am *AppModel) updatePrivateMessage(message lemmy.PrivateMessageView) {
+	counterpartID := am.Messages.addMessage(am.MyUserID, message)
+	am.Messages.signalConversationChanged(counterpartID)
+}
+
+// fetchInboxSource retrieves the items of kind from page on, going on with the
+// next pages while they only have messages sent by the user, myUserID.
+func fetchInboxSource(client *lemmy.Client, ctx context.Context, kind InboxItemKind, page int64, unreadOnly bool, myUserID int64) (items []InboxItem, nextPage int64, exhausted bool, err error) {
+	nextPage = page
+	for {
+		var received int
+		switch kind {
+		case InboxItemReply:
+			var response *lemmy.GetRepliesResponse
+			response, err = client.Replies(ctx, lemmy.GetReplies{
+				Sort:       lemmy.NewOptional(lemmy.CommentSortTypeNew),
+				Page:       lemmy.NewOptional(nextPage + 1),
+				Limit:      lemmy.NewOptional(MAX_INBOX_ITEMS_PER_PAGE),
+				UnreadOnly: lemmy.NewOptional(unreadOnly),
+			})
+			if err != nil {
+				return
+			}
+			received = len(response.Replies)
+			for _, reply := range response.Replies {
+				items = append(items, inboxItemFromReply(reply))
+			}
+		case InboxItemMention:
+			var response *lemmy.GetPersonMentionsResponse
+			response, err = client.PersonMentions(ctx, lemmy.GetPersonMentions{
+				Sort:       lemmy.NewOptional(lemmy.CommentSortTypeNew),
+				Page:       lemmy.NewOptional(nextPage + 1),
+				Limit:      lemmy.NewOptional(MAX_INBOX_ITEMS_PER_PAGE),
+				UnreadOnly: lemmy.NewOptional(unreadOnly),
+			})
+			if err != nil {
+				return
+			}
+			received = len(response.Mentions)
+			for _, mention := range response.Mentions {
+				items = append(items, inboxItemFromMention(mention))
+			}
+		case InboxItemMessage:
+			var response *lemmy.PrivateMessagesResponse
+			response, err = client.PrivateMessages(ctx, lemmy.GetPrivateMessages{
+				Page:       lemmy.NewOptional(nextPage + 1),
+				Limit:      lemmy.NewOptional(MAX_INBOX_ITEMS_PER_PAGE),
+				UnreadOnly: lemmy.NewOptional(unreadOnly),
+			})
+			if err != nil {
+				return
+			}
+			received = len(response.PrivateMessages)
+			for _, message := range response.PrivateMessages {
+				if message.Recipient.ID == myUserID {
+					items = append(items, inboxItemFromMessage(message))
+				}
+			}
+		}
+
+		nextPage++
+		exhausted = int64(received) < MAX_INBOX_ITEMS_PER_PAGE
+		if exhausted || len(items) > 0 {
+			return
+		}
+	}
+}
+
+func (am *AppModel) retrieveFeedPosts(feed *PostFeed, page int64, callback func(error)) {
+	if feed.isRetrieving() {
+		callback(fmt.Errorf("Already retrieving posts, ignoring."))
+		return
+	}
+
+	log.Printf("Retrieving posts from page %d...", page)
+
+	getPosts := lemmy.GetPosts{
+		Type: lemmy.NewOptional(am.getCurrentType()),
+		Page: lemmy.NewOptional(page + 1),
+		Sort: lemmy.NewOptional(am.getCurrentSort()),
+	}
+	if feed.CommunityID != 0 {
+		getPosts.Type = lemmy.NewOptional(lemmy.ListingTypeAll)
+		getPosts.CommunityID = lemmy.NewOptional(feed.CommunityID)
+	}
+
+	processID := fmt.Sprintf("list%d", page)
+	feed.startProcess(processID)
+	client, ctx := am.lemmyClient, feed.requestContext(am.lemmyContext)
+	go func() {
+		response, err := client.Posts(ctx, getPosts)
+		log.Printf("Posts from page %d retrieval completed. Error: %v", page, err)
+		am.callInMain(func() error {
+			if !feed.isProcessPending(processID) {
+				return fmt.Errorf("Process %s no longer needed", processID)
+			}
+			if err != nil {
+				return err
+			}
+
+			return am.addPosts(feed, response.Posts, false, nil)
+		}, func(err error) {
+			feed.endProcess(processID)
+			callback(err)
+		})
+	}()
+}
+
+func (am *AppModel) addCommunities(processID int64, communities []lemmy.CommunityView) {
+	communityModels := make([]*CommunityModel, len(communities))
+	pending := len(communities)
+	finish := func() {
+		for _, communityModel := range communityModels {
+			if communityModel != nil {
+				am.Communities.KnownCommunities[communityModel.Community.ID] = *communityModel
+				am.Communities.lastAddedCommunities = append(am.Communities.lastAddedCommunities, communityModel.Community.ID)
+			}
+		}
+		am.Communities.retrieving = false
+		am.Communities.signalNewCommunities()
+	}
+
+	if pending == 0 {
+		finish()
+		return
+	}
+
+	for idx, community := range communities {
+		communityModel := &CommunityModel{CommunityView: community}
+		communityIdx := idx
+		communityModel.Init(am.lemmyContext, func(err error) {
+			if processID != am.Communities.processID {
+				return
+			}
+
+			if err != nil {
+				log.Printf("Something went wrong with community %d, skipping: %s", communityModel.Community.ID, err)
+			} else {
+				communityModels[communityIdx] = communityModel
+			}
+
+			pending--
+			if pending == 0 {
+				finish()
+			}
+		})
+	}
+}
+
+func (am *AppModel) addPosts(feed *PostFeed, posts []lemmy.PostView, onTop bool, err error) error {
+	if err != nil {
+		log.Println("addPost called with errors, ignoring call.")
+		return err
+	}
+
+	log.Printf("Adding %d new posts to local DB.", len(posts))
+	// Posts are shown right away, their media is loaded by LoadPostMedia once seen.
+	feed.lastAddedPosts = make([]int64, 0, len(posts))
+	feed.lastAddedOnTop = onTop
+	if am.postFeeds == nil {
+		am.postFeeds = make(map[int64]*PostFeed)
+	}
+	for _, post := range posts {
+		postID := post.Post.ID
+		if _, ok := am.KnownPosts[postID]; !ok {
+			am.KnownPosts[postID] = NewPostModel(post)
+			log.Printf("Added new post %d to %p DB with %d posts.", postID, &am.KnownPosts, len(am.KnownPosts))
+		}
+		am.postFeeds[postID] = feed
+		feed.lastAddedPosts = append(feed.lastAddedPosts, postID)
+	}
+
+	feed.signalNewPosts()
+	return err
+}
+
+// LoadPostMedia loads the images, link preview and community icon of postID.
+// The callback isn't called if they're already loaded or being loaded. Loads
+// stop when the feed that listed the post is cleaned, and start over the next
+// time they're asked for.
+func (am *AppModel) LoadPostMedia(postID int64, callback func(error)) {
+	post, ok := am.KnownPosts[postID]
+	if !ok {
+		callback(fmt.Errorf("Post %d no longer in local DB", postID))
+		return
+	}
+	if post.MediaLoaded || am.loadingMedia[postID] {
+		return
+	}
+
+	if am.loadingMedia == nil {
+		am.loadingMedia = make(map[int64]bool)
+	}
+	am.loadingMedia[postID] = true
+	ctx := am.lemmyContext
+	if feed, ok := am.postFeeds[postID]; ok {
+		ctx = feed.requestContext(am.lemmyContext)
+	}
+	post.LoadMedia(ctx, func() {
+		delete(am.loadingMedia, postID)
+		if ctx.Err() != nil {
+			callback(ctx.Err())
+			return
+		}
+
+		// Only the media is taken, the rest of the post may have changed meanwhile.
+		knownPost, ok := am.KnownPosts[postID]
+		if !ok {
+			callback(fmt.Errorf("Post %d no longer in local DB", postID))
+			return
+		}
+		knownPost.PostMedia = post.PostMedia
+		am.KnownPosts[postID] = knownPost
+		callback(nil)
+	})
+}
+
+func (am *AppModel) updateComment(postID int64, comment lemmy.CommentView) error {
+	post, ok := am.KnownPosts[postID]
+	if !ok {
+		return fmt.Errorf("Post %d no longer in local DB", postID)
+	}
+
+	if post.UpdateComment(comment) == nil {
+		return fmt.Errorf("Comment %d couldn't be found in post %d", comment.Comment.ID, postID)
+	}
+	return nil
+}
+
+func (am *AppModel) getCurrentSort() lemmy.SortType {
+	order := am.Configuration.GetOrder()
+	switch order {
+	case PostOrderActive:
+		return lemmy.SortTypeActive
+	case PostOrderHot:
+		return lemmy.SortTypeHot
+	case PostOrderScaled:
+		return lemmy.SortTypeScaled
+	case PostOrderControversial:
+		return lemmy.SortTypeControversial
+	case PostOrderNew:
+		return lemmy.SortTypeNew
+	case PostOrderOld:
+		return lemmy.SortTypeOld
+	case PostOderMostComments:
+		return lemmy.SortTypeMostComments
+	case PostOrderNewComments:
+		return lemmy.SortTypeNewComments
+	}
+	return lemmy.SortTypeActive
+}
+
+func (am *AppModel) getCurrentType() lemmy.ListingType {
+	return listingTypeFor(am.Configuration.GetFilter())
+}
+
+func listingTypeFor(filter PostsFilter) lemmy.ListingType {
+	switch filter {
+	case PostFilterSubscribed:
+		return lemmy.ListingTypeSubscribed
+	case PostFilterLocal:
+		return lemmy.ListingTypeLocal
+	case PostFilterAll:
+		return lemmy.ListingTypeAll
+	}
+	return lemmy.ListingTypeSubscribed
+}
+
+// callInMain runs function and then callback in the GTK main loop. It's the way
+// back for every request, so it's also where an expired session is noticed.
+func (am *AppModel) callInMain(function func() error, callback func(error)) {
+	glib.IdleAdd(func() bool {
+		err := sessionError(function())
+		if errors.Is(err, ErrSessionExpired) && am.SessionExpired != nil {
+			am.SessionExpired()
+		}
 		callback(err)
 		return false
 	})
@@ -1,27 +1,60 @@
 package model
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"log"
 	"os"
 	"path"
+	"time"
 
 	"github.com/gotk3/gotk3/glib"
 )
 
 type AppModelConfiguration struct {
-	config   ConfigData
-	filepath string
+	config      ConfigData
+	filepath    string
+	credentials CredentialStore
+	tokens      map[string]string
 }
 
-const configDirName = "lemmeread"
+const (
+	configDirName              = "lemmeread"
+	credentialsFilename        = "credentials.json"
+	defaultInboxPollingSeconds = 60
+	defaultImageCacheMB        = 200
+	imageCacheDirName          = "images"
+)
 
 type ConfigData struct {
-	LemmyServer string      `json:"lemmyServer"`
-	LemmyToken  string      `json:"lemmyToken"`
-	Order       PostsOrder  `json:"order"`
-	Filter      PostsFilter `json:"filter"`
+	Accounts            []AccountData `json:"accounts"`
+	CurrentAccount      int           `json:"currentAccount"`
+	InboxPollingSeconds int           `json:"inboxPollingSeconds"`
+	ImageCacheMB        int           `json:"imageCacheMB"`
+	// Proxy overrides the one in the environment, e.g. "socks5://localhost:9050".
+	Proxy string `json:"proxy,omitempty"`
+
+	// Single account fields from older configurations, migrated into Accounts on load.
+	LemmyServer string      `json:"lemmyServer,omitempty"`
+	LemmyToken  string      `json:"lemmyToken,omitempty"`
+	Order       PostsOrder  `json:"order,omitempty"`
+	Filter      PostsFilter `json:"filter,omitempty"`
+}
+
+type AccountData struct {
+	ID       string      `json:"id"`
+	Server   string      `json:"server"`
+	Username string      `json:"username"`
+	Order    PostsOrder  `json:"order"`
+	Filter   PostsFilter `json:"filter"`
+
+	// Anonymous accounts only browse the public content of Server, without a token.
+	Anonymous bool `json:"anonymous,omitempty"`
+
+	// Plain text token from older configurations, moved to the CredentialStore on load.
+	Token string `json:"token,omitempty"`
 }
 
 type PostsOrder int
@@ -53,6 +86,8 @@ func NewAppModelConfiguration(configFilename string) (amc AppModelConfiguration)
 	}
 
 	amc.filepath = path.Join(configDir, configFilename)
+	amc.credentials = NewDefaultCredentialStore(path.Join(configDir, credentialsFilename))
+	amc.tokens = make(map[string]string)
 
 	_, err = os.Stat(amc.filepath)
 	if os.IsNotExist(err) {
@@ -71,49 +106,269 @@ func NewAppModelConfiguration(configFilename string) (amc AppModelConfiguration)
 		log.Println(err)
 	}
 
+	migratedAccount := amc.migrateSingleAccount()
+	migratedTokens := amc.migrateTokens()
+	if migratedAccount || migratedTokens {
+		err = amc.saveConfig()
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
 	return
 }
 
-func (amc *AppModelConfiguration) GetLemmyServer() string {
-	return amc.config.LemmyServer
+func (amc *AppModelConfiguration) GetAccounts() []AccountData {
+	return amc.config.Accounts
 }
 
-func (amc *AppModelConfiguration) SetLemmyServer(server string) {
-	amc.config.LemmyServer = server
+func (amc *AppModelConfiguration) GetCurrentAccount() int {
+	return amc.config.CurrentAccount
+}
+
+func (amc *AppModelConfiguration) SetCurrentAccount(index int) {
+	if index < 0 || index >= len(amc.config.Accounts) {
+		return
+	}
+	amc.config.CurrentAccount = index
 	amc.saveConfig()
 }
 
+func (amc *AppModelConfiguration) AddAccount(server string, username string, token string) {
+	for index, account := range amc.config.Accounts {
+		if account.Server == server && account.Username == username {
+			amc.storeToken(&amc.config.Accounts[index], token)
+			amc.config.CurrentAccount = index
+			amc.saveConfig()
+			return
+		}
+	}
+
+	amc.config.Accounts = append(amc.config.Accounts, AccountData{ID: newAccountID(), Server: server, Username: username})
+	amc.config.CurrentAccount = len(amc.config.Accounts) - 1
+	amc.storeToken(&amc.config.Accounts[amc.config.CurrentAccount], token)
+	amc.saveConfig()
+}
+
+// AddAnonymousAccount makes browsing server without logging in the current
+// account, reusing the existing anonymous entry for that server if any.
+func (amc *AppModelConfiguration) AddAnonymousAccount(server string) {
+	for index, account := range amc.config.Accounts {
+		if account.Server == server && account.Anonymous {
+			amc.config.CurrentAccount = index
+			amc.saveConfig()
+			return
+		}
+	}
+
+	// There are no subscriptions to show without an account.
+	amc.config.Accounts = append(amc.config.Accounts, AccountData{ID: newAccountID(), Server: server, Filter: PostFilterLocal, Anonymous: true})
+	amc.config.CurrentAccount = len(amc.config.Accounts) - 1
+	amc.saveConfig()
+}
+
+func (amc *AppModelConfiguration) RemoveAccount(index int) {
+	if index < 0 || index >= len(amc.config.Accounts) {
+		return
+	}
+
+	accountID := amc.config.Accounts[index].ID
+	delete(amc.tokens, accountID)
+	err := amc.credentials.DeleteToken(accountID)
+	if err != nil {
+		log.Println(err)
+	}
+
+	amc.config.Accounts = append(amc.config.Accounts[:index], amc.config.Accounts[index+1:]...)
+	if amc.config.CurrentAccount >= index && amc.config.CurrentAccount > 0 {
+		amc.config.CurrentAccount--
+	}
+	amc.saveConfig()
+}
+
+func (amc *AppModelConfiguration) SetUsername(username string) {
+	if account := amc.currentAccount(); account != nil && account.Username != username {
+		account.Username = username
+		amc.saveConfig()
+	}
+}
+
+func (amc *AppModelConfiguration) GetLemmyServer() string {
+	if account := amc.currentAccount(); account != nil {
+		return account.Server
+	}
+	return ""
+}
+
+func (amc *AppModelConfiguration) GetUsername() string {
+	if account := amc.currentAccount(); account != nil {
+		return account.Username
+	}
+	return ""
+}
+
 func (amc *AppModelConfiguration) HaveLemmyData() bool {
-	return amc.config.LemmyToken != "" && amc.config.LemmyServer != ""
+	account := amc.currentAccount()
+	return account != nil && account.Server != "" && (account.Anonymous || amc.GetLemmyToken() != "")
+}
+
+func (amc *AppModelConfiguration) IsAnonymous() bool {
+	account := amc.currentAccount()
+	return account != nil && account.Anonymous
 }
 
 func (amc *AppModelConfiguration) GetLemmyToken() string {
-	return amc.config.LemmyToken
+	account := amc.currentAccount()
+	if account == nil || account.Anonymous {
+		return ""
+	}
+
+	if token, ok := amc.tokens[account.ID]; ok {
+		return token
+	}
+
+	token, err := amc.credentials.GetToken(account.ID)
+	if err != nil {
+		log.Printf("Couldn't retrieve token for %s: %s", account.Server, err)
+		return ""
+	}
+	amc.tokens[account.ID] = token
+	return token
 }
 
 func (amc *AppModelConfiguration) SetLemmyToken(token string) {
-	amc.config.LemmyToken = token
-	amc.saveConfig()
+	if account := amc.currentAccount(); account != nil {
+		amc.storeToken(account, token)
+	}
 }
 
 func (amc *AppModelConfiguration) GetOrder() PostsOrder {
-	return amc.config.Order
+	if account := amc.currentAccount(); account != nil {
+		return account.Order
+	}
+	return PostOrderActive
 }
 
 func (amc *AppModelConfiguration) SetOrder(order PostsOrder) {
-	amc.config.Order = order
-	amc.saveConfig()
+	if account := amc.currentAccount(); account != nil {
+		account.Order = order
+		amc.saveConfig()
+	}
 }
 
 func (amc *AppModelConfiguration) GetFilter() PostsFilter {
-	return amc.config.Filter
+	if account := amc.currentAccount(); account != nil {
+		return account.Filter
+	}
+	return PostFilterSubscribed
 }
 
 func (amc *AppModelConfiguration) SetFilter(filter PostsFilter) {
-	amc.config.Filter = filter
+	if account := amc.currentAccount(); account != nil {
+		account.Filter = filter
+		amc.saveConfig()
+	}
+}
+
+func (amc *AppModelConfiguration) GetInboxPollingInterval() time.Duration {
+	if amc.config.InboxPollingSeconds <= 0 {
+		return defaultInboxPollingSeconds * time.Second
+	}
+	return time.Duration(amc.config.InboxPollingSeconds) * time.Second
+}
+
+func (amc *AppModelConfiguration) SetInboxPollingInterval(interval time.Duration) {
+	amc.config.InboxPollingSeconds = int(interval.Seconds())
 	amc.saveConfig()
 }
 
+// GetImageCacheSize returns how many bytes of images can be kept on disk.
+func (amc *AppModelConfiguration) GetImageCacheSize() int64 {
+	if amc.config.ImageCacheMB <= 0 {
+		return defaultImageCacheMB << 20
+	}
+	return int64(amc.config.ImageCacheMB) << 20
+}
+
+func (amc *AppModelConfiguration) SetImageCacheSize(size int64) {
+	amc.config.ImageCacheMB = int(size >> 20)
+	amc.saveConfig()
+}
+
+// GetProxy returns the proxy every request goes through, empty to use the one
+// in the environment, if any.
+func (amc *AppModelConfiguration) GetProxy() string {
+	return amc.config.Proxy
+}
+
+func (amc *AppModelConfiguration) currentAccount() *AccountData {
+	if amc.config.CurrentAccount < 0 || amc.config.CurrentAccount >= len(amc.config.Accounts) {
+		return nil
+	}
+	return &amc.config.Accounts[amc.config.CurrentAccount]
+}
+
+func (amc *AppModelConfiguration) migrateSingleAccount() bool {
+	if len(amc.config.Accounts) > 0 || amc.config.LemmyServer == "" {
+		return false
+	}
+
+	log.Println("Migrating single account configuration to account list.")
+	amc.config.Accounts = []AccountData{{
+		Server: amc.config.LemmyServer,
+		Token:  amc.config.LemmyToken,
+		Order:  amc.config.Order,
+		Filter: amc.config.Filter,
+	}}
+	amc.config.CurrentAccount = 0
+	amc.config.LemmyServer = ""
+	amc.config.LemmyToken = ""
+	amc.config.Order = PostOrderActive
+	amc.config.Filter = PostFilterSubscribed
+	return true
+}
+
+// migrateTokens gives every account an ID and moves any plain text token into
+// the credential store. Tokens the store doesn't take stay in the configuration
+// file, so they aren't lost, and are moved on a later start.
+func (amc *AppModelConfiguration) migrateTokens() (changed bool) {
+	for index := range amc.config.Accounts {
+		account := &amc.config.Accounts[index]
+		if account.ID == "" {
+			account.ID = newAccountID()
+			changed = true
+		}
+
+		if account.Token != "" {
+			log.Printf("Moving token for %s out of the configuration file.", account.Server)
+			if amc.storeToken(account, account.Token) {
+				account.Token = ""
+				changed = true
+			}
+		}
+	}
+	return
+}
+
+func (amc *AppModelConfiguration) storeToken(account *AccountData, token string) bool {
+	amc.tokens[account.ID] = token
+	err := amc.credentials.SetToken(account.ID, account.Server, token)
+	if err != nil {
+		log.Printf("Couldn't store token for %s: %s", account.Server, err)
+		return false
+	}
+	return true
+}
+
+func newAccountID() string {
+	id := make([]byte, 8)
+	_, err := rand.Read(id)
+	if err != nil {
+		log.Panic(err)
+	}
+	return hex.EncodeToString(id)
+}
+
 func (amc *AppModelConfiguration) loadConfig() (err error) {
 	var file *os.File
 	file, err = os.Open(amc.filepath)
@@ -137,6 +392,10 @@ func (amc *AppModelConfiguration) saveConfig() (err error) {
 		return
 	}
 
-	err = os.WriteFile(amc.filepath, jsonData, 0644)
-	return
+	err = os.WriteFile(amc.filepath, jsonData, 0600)
+	if err != nil {
+		return
+	}
+	// Older versions wrote it world readable, with tokens in it.
+	return os.Chmod(amc.filepath, 0600)
 }
@@ -1,6 +1,9 @@
 package model
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/gotk3/gotk3/gdk"
 	"go.elara.ws/go-lemmy"
 )
@@ -10,3 +13,43 @@ type CommentModel struct {
 	UserIcon      gdk.Pixbuf
 	ChildComments []*CommentModel
 }
+
+func (cm *CommentModel) ParentID() int64 {
+	pathIDs := strings.Split(cm.Comment.Path, ".")
+	if len(pathIDs) < 3 {
+		return 0
+	}
+
+	parentID, err := strconv.ParseInt(pathIDs[len(pathIDs)-2], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return parentID
+}
+
+func (cm *CommentModel) SetMyVote(score int64) {
+	previous := cm.MyVote.ValueOrZero()
+	cm.Counts.Score += score - previous
+	cm.Counts.Upvotes += boolToInt(score > 0) - boolToInt(previous > 0)
+	cm.Counts.Downvotes += boolToInt(score < 0) - boolToInt(previous < 0)
+	cm.MyVote = lemmy.NewOptional(score)
+}
+
+func findComment(comments []*CommentModel, commentID int64) *CommentModel {
+	for _, comment := range comments {
+		if comment.Comment.ID == commentID {
+			return comment
+		}
+		if found := findComment(comment.ChildComments, commentID); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func boolToInt(value bool) int64 {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"go.elara.ws/go-lemmy"
+)
+
+type CommunitiesOrder int
+
+const (
+	CommunitiesOrderTopAll = iota
+	CommunitiesOrderActive
+	CommunitiesOrderHot
+	CommunitiesOrderNew
+	CommunitiesOrderOld
+)
+
+type CommunityListModel struct {
+	KnownCommunities map[int64]CommunityModel
+	NewCommunities   func()
+	Order            CommunitiesOrder
+	Filter           PostsFilter
+
+	lastAddedCommunities []int64
+	nextPageToRetrieve   int64
+	processID            int64
+	retrieving           bool
+}
+
+func (clm *CommunityListModel) CleanList() {
+	clm.KnownCommunities = make(map[int64]CommunityModel)
+	clm.lastAddedCommunities = make([]int64, 0)
+	clm.nextPageToRetrieve = 0
+	clm.processID++
+	clm.retrieving = false
+}
+
+func (clm *CommunityListModel) ConsumeLastAddedCommunities() []CommunityModel {
+	communities := make([]CommunityModel, 0, len(clm.lastAddedCommunities))
+	for _, communityID := range clm.lastAddedCommunities {
+		communities = append(communities, clm.KnownCommunities[communityID])
+	}
+	clm.lastAddedCommunities = make([]int64, 0)
+	return communities
+}
+
+func (clm *CommunityListModel) getSort() lemmy.SortType {
+	switch clm.Order {
+	case CommunitiesOrderTopAll:
+		return lemmy.SortTypeTopAll
+	case CommunitiesOrderActive:
+		return lemmy.SortTypeActive
+	case CommunitiesOrderHot:
+		return lemmy.SortTypeHot
+	case CommunitiesOrderNew:
+		return lemmy.SortTypeNew
+	case CommunitiesOrderOld:
+		return lemmy.SortTypeOld
+	}
+	return lemmy.SortTypeTopAll
+}
+
+func (clm *CommunityListModel) getType() lemmy.ListingType {
+	return listingTypeFor(clm.Filter)
+}
+
+func (clm *CommunityListModel) signalNewCommunities() {
+	if clm.NewCommunities != nil {
+		clm.NewCommunities()
+	}
+}
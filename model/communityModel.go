@@ -0,0 +1,60 @@
+package model
+
+import (
+	"context"
+	"log"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/mjdiliscia/LemmeRead/utils"
+	"go.elara.ws/go-lemmy"
+)
+
+type CommunityModel struct {
+	lemmy.CommunityView
+	Icon   *gdk.Pixbuf
+	Banner *gdk.Pixbuf
+}
+
+func (cm *CommunityModel) Init(ctx context.Context, callback func(error)) {
+	var taskSequence *utils.TaskSequence[*gdk.Pixbuf]
+	taskSequence = utils.NewTaskSequence[*gdk.Pixbuf](func() {
+		taskSequence = nil
+		callback(nil)
+	})
+
+	taskSequence.Add(cm.getPixbufTask(ctx, cm.Community.Icon), func(pixbuf *gdk.Pixbuf, err error) bool {
+		if err != nil {
+			log.Println(err)
+		} else {
+			cm.Icon = pixbuf
+		}
+		return true
+	})
+	taskSequence.Add(cm.getPixbufTask(ctx, cm.Community.Banner), func(pixbuf *gdk.Pixbuf, err error) bool {
+		if err != nil {
+			log.Println(err)
+		} else {
+			cm.Banner = pixbuf
+		}
+		return true
+	})
+
+	taskSequence.Execute()
+}
+
+func (cm *CommunityModel) IsSubscribed() bool {
+	return cm.Subscribed != lemmy.SubscribedTypeNotSubscribed
+}
+
+func (cm *CommunityModel) IsSubscriptionPending() bool {
+	return cm.Subscribed == lemmy.SubscribedTypePending
+}
+
+func (cm *CommunityModel) getPixbufTask(ctx context.Context, url lemmy.Optional[string]) func() (*gdk.Pixbuf, error) {
+	return func() (*gdk.Pixbuf, error) {
+		if url.IsValid() {
+			return utils.LoadPixmapFromUrl(ctx, url.ValueOrZero())
+		}
+		return nil, nil
+	}
+}
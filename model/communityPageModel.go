@@ -0,0 +1,12 @@
+package model
+
+type CommunityPageModel struct {
+	PostFeed
+	Community CommunityModel
+}
+
+func (cpm *CommunityPageModel) CleanPage(communityID int64) {
+	cpm.CleanFeed()
+	cpm.CommunityID = communityID
+	cpm.Community = CommunityModel{}
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"go.elara.ws/go-lemmy"
+)
+
+type PrivateMessageModel struct {
+	lemmy.PrivateMessageView
+}
+
+type ConversationModel struct {
+	Counterpart lemmy.Person
+	Messages    []PrivateMessageModel
+}
+
+func (cm *ConversationModel) LastMessage() (PrivateMessageModel, bool) {
+	if len(cm.Messages) == 0 {
+		return PrivateMessageModel{}, false
+	}
+	return cm.Messages[len(cm.Messages)-1], true
+}
+
+func (cm *ConversationModel) UnreadMessages(myUserID int64) []int64 {
+	unread := make([]int64, 0)
+	for _, message := range cm.Messages {
+		if !message.PrivateMessage.Read && message.Recipient.ID == myUserID {
+			unread = append(unread, message.PrivateMessage.ID)
+		}
+	}
+	return unread
+}
+
+func (cm *ConversationModel) FindMessage(messageID int64) *PrivateMessageModel {
+	index := slices.IndexFunc(cm.Messages, func(message PrivateMessageModel) bool {
+		return message.PrivateMessage.ID == messageID
+	})
+	if index == -1 {
+		return nil
+	}
+	return &cm.Messages[index]
+}
+
+func (cm *ConversationModel) upsertMessage(message lemmy.PrivateMessageView) {
+	if existing := cm.FindMessage(message.PrivateMessage.ID); existing != nil {
+		existing.PrivateMessageView = message
+		return
+	}
+
+	index, _ := slices.BinarySearchFunc(cm.Messages, message.PrivateMessage.Published, func(message PrivateMessageModel, published time.Time) int {
+		return message.PrivateMessage.Published.Compare(published)
+	})
+	cm.Messages = slices.Insert(cm.Messages, index, PrivateMessageModel{PrivateMessageView: message})
+}
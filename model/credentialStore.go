@@ -0,0 +1,136 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+// CredentialStore keeps account tokens out of the configuration file. Accounts
+// are identified by AccountData.ID.
+type CredentialStore interface {
+	GetToken(accountID string) (string, error)
+	SetToken(accountID string, server string, token string) error
+	DeleteToken(accountID string) error
+}
+
+// NewDefaultCredentialStore prefers the Secret Service keyring and falls back to
+// a private file next to the configuration when no keyring is reachable.
+func NewDefaultCredentialStore(credentialsPath string) CredentialStore {
+	fileStore := NewFileCredentialStore(credentialsPath)
+	if !utils.SecretServiceAvailable() {
+		log.Println("No Secret Service available, storing tokens in a private file.")
+		return fileStore
+	}
+	return &fallbackCredentialStore{primary: SecretServiceCredentialStore{}, fallback: fileStore}
+}
+
+type SecretServiceCredentialStore struct{}
+
+func (SecretServiceCredentialStore) GetToken(accountID string) (string, error) {
+	return utils.LookupSecretToken(accountID)
+}
+
+func (SecretServiceCredentialStore) SetToken(accountID string, server string, token string) error {
+	return utils.StoreSecretToken(accountID, server, fmt.Sprintf("LemmeRead token for %s", server), token)
+}
+
+func (SecretServiceCredentialStore) DeleteToken(accountID string) error {
+	return utils.ClearSecretToken(accountID)
+}
+
+type FileCredentialStore struct {
+	filepath string
+}
+
+func NewFileCredentialStore(filepath string) *FileCredentialStore {
+	return &FileCredentialStore{filepath: filepath}
+}
+
+func (fcs *FileCredentialStore) GetToken(accountID string) (string, error) {
+	tokens, err := fcs.load()
+	return tokens[accountID], err
+}
+
+func (fcs *FileCredentialStore) SetToken(accountID string, server string, token string) error {
+	tokens, err := fcs.load()
+	if err != nil {
+		return err
+	}
+	tokens[accountID] = token
+	return fcs.save(tokens)
+}
+
+func (fcs *FileCredentialStore) DeleteToken(accountID string) error {
+	tokens, err := fcs.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := tokens[accountID]; !ok {
+		return nil
+	}
+	delete(tokens, accountID)
+	return fcs.save(tokens)
+}
+
+func (fcs *FileCredentialStore) load() (tokens map[string]string, err error) {
+	tokens = make(map[string]string)
+	jsonData, err := os.ReadFile(fcs.filepath)
+	if errors.Is(err, os.ErrNotExist) {
+		return tokens, nil
+	} else if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(jsonData, &tokens)
+	return
+}
+
+func (fcs *FileCredentialStore) save(tokens map[string]string) (err error) {
+	jsonData, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return
+	}
+
+	err = os.WriteFile(fcs.filepath, jsonData, 0600)
+	if err != nil {
+		return
+	}
+	// WriteFile keeps the mode of an existing file, so tighten it explicitly.
+	return os.Chmod(fcs.filepath, 0600)
+}
+
+// fallbackCredentialStore writes to the primary store and only uses the fallback
+// when the primary one fails, e.g. because the user dismissed the unlock prompt.
+type fallbackCredentialStore struct {
+	primary  CredentialStore
+	fallback CredentialStore
+}
+
+func (fcs *fallbackCredentialStore) GetToken(accountID string) (string, error) {
+	token, err := fcs.primary.GetToken(accountID)
+	if err == nil && token != "" {
+		return token, nil
+	}
+	if err != nil {
+		log.Println(err)
+	}
+	return fcs.fallback.GetToken(accountID)
+}
+
+func (fcs *fallbackCredentialStore) SetToken(accountID string, server string, token string) error {
+	err := fcs.primary.SetToken(accountID, server, token)
+	if err != nil {
+		log.Printf("Couldn't store token in primary store, using fallback: %s", err)
+		return fcs.fallback.SetToken(accountID, server, token)
+	}
+	return fcs.fallback.DeleteToken(accountID)
+}
+
+func (fcs *fallbackCredentialStore) DeleteToken(accountID string) error {
+	return errors.Join(fcs.primary.DeleteToken(accountID), fcs.fallback.DeleteToken(accountID))
+}
@@ -0,0 +1,182 @@
+package model
+
+import (
+	"errors"
+	"os"
+	"path"
+	"testing"
+)
+
+// mockCredentialStore keeps tokens in memory, failing every call while broken.
+type mockCredentialStore struct {
+	tokens map[string]string
+	broken bool
+}
+
+func newMockCredentialStore() *mockCredentialStore {
+	return &mockCredentialStore{tokens: make(map[string]string)}
+}
+
+func (mcs *mockCredentialStore) GetToken(accountID string) (string, error) {
+	if mcs.broken {
+		return "", errors.New("keyring locked")
+	}
+	return mcs.tokens[accountID], nil
+}
+
+func (mcs *mockCredentialStore) SetToken(accountID string, server string, token string) error {
+	if mcs.broken {
+		return errors.New("keyring locked")
+	}
+	mcs.tokens[accountID] = token
+	return nil
+}
+
+func (mcs *mockCredentialStore) DeleteToken(accountID string) error {
+	if mcs.broken {
+		return errors.New("keyring locked")
+	}
+	delete(mcs.tokens, accountID)
+	return nil
+}
+
+func newTestConfiguration(t *testing.T, credentials CredentialStore, accounts ...AccountData) AppModelConfiguration {
+	return AppModelConfiguration{
+		config:      ConfigData{Accounts: accounts},
+		filepath:    path.Join(t.TempDir(), "config.json"),
+		credentials: credentials,
+		tokens:      make(map[string]string),
+	}
+}
+
+func assertFileMode(t *testing.T, filepath string, expected os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(filepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != expected {
+		t.Errorf("%s has mode %v, expected %v", filepath, info.Mode().Perm(), expected)
+	}
+}
+
+func TestMigrateTokensMovesTokensToStore(t *testing.T) {
+	store := newMockCredentialStore()
+	amc := newTestConfiguration(t, store, AccountData{Server: "lemmy.example", Token: "secret"})
+
+	if !amc.migrateTokens() {
+		t.Fatal("migration reported no changes")
+	}
+
+	account := amc.config.Accounts[0]
+	if account.ID == "" {
+		t.Error("account wasn't given an ID")
+	}
+	if account.Token != "" {
+		t.Error("token was left in the configuration")
+	}
+	if store.tokens[account.ID] != "secret" {
+		t.Errorf("store has token %q, expected %q", store.tokens[account.ID], "secret")
+	}
+}
+
+func TestMigrateTokensKeepsTokenWhenStoreFails(t *testing.T) {
+	store := newMockCredentialStore()
+	store.broken = true
+	amc := newTestConfiguration(t, store, AccountData{ID: "account", Server: "lemmy.example", Token: "secret"})
+
+	if amc.migrateTokens() {
+		t.Error("migration reported changes without storing the token")
+	}
+	if amc.config.Accounts[0].Token != "secret" {
+		t.Error("token was dropped from the configuration")
+	}
+	if token := amc.GetLemmyToken(); token != "secret" {
+		t.Errorf("session token is %q, expected %q", token, "secret")
+	}
+
+	// It's moved once the store works again.
+	store.broken = false
+	if !amc.migrateTokens() {
+		t.Fatal("migration reported no changes")
+	}
+	if amc.config.Accounts[0].Token != "" || store.tokens["account"] != "secret" {
+		t.Error("token wasn't moved to the store")
+	}
+}
+
+func TestSaveConfigIsPrivate(t *testing.T) {
+	amc := newTestConfiguration(t, newMockCredentialStore())
+	err := os.WriteFile(amc.filepath, []byte("{}"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = amc.saveConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFileMode(t, amc.filepath, 0600)
+}
+
+func TestFileCredentialStore(t *testing.T) {
+	filepath := path.Join(t.TempDir(), credentialsFilename)
+	err := os.WriteFile(filepath, []byte("{}"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewFileCredentialStore(filepath)
+
+	err = store.SetToken("account", "lemmy.example", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFileMode(t, filepath, 0600)
+
+	token, err := store.GetToken("account")
+	if err != nil || token != "secret" {
+		t.Errorf("got token %q (%v), expected %q", token, err, "secret")
+	}
+
+	err = store.DeleteToken("account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err = store.GetToken("account")
+	if err != nil || token != "" {
+		t.Errorf("got token %q (%v) after deleting it", token, err)
+	}
+}
+
+func TestFallbackCredentialStore(t *testing.T) {
+	primary := newMockCredentialStore()
+	fallback := newMockCredentialStore()
+	store := &fallbackCredentialStore{primary: primary, fallback: fallback}
+
+	primary.broken = true
+	err := store.SetToken("account", "lemmy.example", "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fallback.tokens["account"] != "old" {
+		t.Error("token didn't reach the fallback store")
+	}
+	if token, _ := store.GetToken("account"); token != "old" {
+		t.Errorf("got token %q from fallback, expected %q", token, "old")
+	}
+
+	primary.broken = false
+	err = store.SetToken("account", "lemmy.example", "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if primary.tokens["account"] != "new" {
+		t.Error("token didn't reach the primary store")
+	}
+	if _, ok := fallback.tokens["account"]; ok {
+		t.Error("fallback store kept a stale token")
+	}
+	if token, _ := store.GetToken("account"); token != "new" {
+		t.Errorf("got token %q, expected %q", token, "new")
+	}
+}
@@ -0,0 +1,217 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"go.elara.ws/go-lemmy"
+)
+
+type InboxItemKind int
+
+const (
+	InboxItemReply = iota
+	InboxItemMention
+	InboxItemMessage
+)
+
+type InboxItem struct {
+	Kind      InboxItemKind
+	ID        int64
+	PostID    int64
+	CommentID int64
+	Author    lemmy.Person
+	Title     string
+	Content   string
+	Published time.Time
+	Read      bool
+}
+
+type InboxModel struct {
+	Items         []InboxItem
+	UnreadOnly    bool
+	NewItems      func()
+	UnreadChanged func()
+
+	unreadReplies  int64
+	unreadMentions int64
+	unreadMessages int64
+	lastAddedItems []InboxItem
+	sources        [3]inboxSource
+	processID      int64
+	retrieving     bool
+}
+
+// inboxSource is one of the paged lists the inbox is merged from, indexed by
+// InboxItemKind. Its items wait in pending until no other list can have newer
+// ones.
+type inboxSource struct {
+	pending            []InboxItem
+	nextPageToRetrieve int64
+	exhausted          bool
+}
+
+func (im *InboxModel) CleanInbox(unreadOnly bool) {
+	im.Items = make([]InboxItem, 0)
+	im.UnreadOnly = unreadOnly
+	im.lastAddedItems = make([]InboxItem, 0)
+	im.sources = [3]inboxSource{}
+	im.processID++
+	im.retrieving = false
+}
+
+func (im *InboxModel) UnreadCount() int64 {
+	return im.unreadReplies + im.unreadMentions + im.unreadMessages
+}
+
+func (im *InboxModel) ConsumeLastAddedItems() []InboxItem {
+	items := im.lastAddedItems
+	im.lastAddedItems = make([]InboxItem, 0)
+	return items
+}
+
+func (im *InboxModel) FindItem(kind InboxItemKind, id int64) *InboxItem {
+	index := slices.IndexFunc(im.Items, func(item InboxItem) bool {
+		return item.Kind == kind && item.ID == id
+	})
+	if index == -1 {
+		return nil
+	}
+	return &im.Items[index]
+}
+
+func (im *InboxModel) addItems(items []InboxItem) {
+	im.Items = append(im.Items, items...)
+	im.lastAddedItems = append(im.lastAddedItems, items...)
+}
+
+func (im *InboxModel) isExhausted() bool {
+	for _, source := range im.sources {
+		if !source.exhausted || len(source.pending) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// sourcesToRetrieve returns the kinds whose next page is needed to tell which
+// item comes next.
+func (im *InboxModel) sourcesToRetrieve() (kinds []InboxItemKind) {
+	for kind, source := range im.sources {
+		if len(source.pending) == 0 && !source.exhausted {
+			kinds = append(kinds, InboxItemKind(kind))
+		}
+	}
+	return
+}
+
+func (im *InboxModel) addSourceItems(kind InboxItemKind, items []InboxItem, nextPage int64, exhausted bool) {
+	source := &im.sources[kind]
+	source.pending = append(source.pending, items...)
+	slices.SortStableFunc(source.pending, func(a InboxItem, b InboxItem) int {
+		return b.Published.Compare(a.Published)
+	})
+	source.nextPageToRetrieve = nextPage
+	source.exhausted = exhausted
+}
+
+// mergeSources takes up to limit pending items, newest first, stopping when a
+// source that may have newer items than the rest runs out of pending ones.
+func (im *InboxModel) mergeSources(limit int) (items []InboxItem) {
+	for len(items) < limit {
+		var newest *inboxSource
+		for index := range im.sources {
+			source := &im.sources[index]
+			if len(source.pending) == 0 {
+				if !source.exhausted {
+					return
+				}
+				continue
+			}
+			if newest == nil || source.pending[0].Published.After(newest.pending[0].Published) {
+				newest = source
+			}
+		}
+		if newest == nil {
+			return
+		}
+
+		items = append(items, newest.pending[0])
+		newest.pending = newest.pending[1:]
+	}
+	return
+}
+
+func (im *InboxModel) setUnreadCounts(replies int64, mentions int64, messages int64) {
+	changed := replies != im.unreadReplies || mentions != im.unreadMentions || messages != im.unreadMessages
+	im.unreadReplies = replies
+	im.unreadMentions = mentions
+	im.unreadMessages = messages
+	if changed {
+		im.signalUnreadChanged()
+	}
+}
+
+func (im *InboxModel) markRead(kind InboxItemKind) {
+	switch kind {
+	case InboxItemReply:
+		im.unreadReplies = max(im.unreadReplies-1, 0)
+	case InboxItemMention:
+		im.unreadMentions = max(im.unreadMentions-1, 0)
+	case InboxItemMessage:
+		im.unreadMessages = max(im.unreadMessages-1, 0)
+	}
+	im.signalUnreadChanged()
+}
+
+func (im *InboxModel) signalNewItems() {
+	if im.NewItems != nil {
+		im.NewItems()
+	}
+}
+
+func (im *InboxModel) signalUnreadChanged() {
+	if im.UnreadChanged != nil {
+		im.UnreadChanged()
+	}
+}
+
+func inboxItemFromReply(reply lemmy.CommentReplyView) InboxItem {
+	return InboxItem{
+		Kind:      InboxItemReply,
+		ID:        reply.CommentReply.ID,
+		PostID:    reply.Post.ID,
+		CommentID: reply.Comment.ID,
+		Author:    reply.Creator,
+		Title:     reply.Post.Name,
+		Content:   reply.Comment.Content,
+		Published: reply.Comment.Published,
+		Read:      reply.CommentReply.Read,
+	}
+}
+
+func inboxItemFromMention(mention lemmy.PersonMentionView) InboxItem {
+	return InboxItem{
+		Kind:      InboxItemMention,
+		ID:        mention.PersonMention.ID,
+		PostID:    mention.Post.ID,
+		CommentID: mention.Comment.ID,
+		Author:    mention.Creator,
+		Title:     mention.Post.Name,
+		Content:   mention.Comment.Content,
+		Published: mention.Comment.Published,
+		Read:      mention.PersonMention.Read,
+	}
+}
+
+func inboxItemFromMessage(message lemmy.PrivateMessageView) InboxItem {
+	return InboxItem{
+		Kind:      InboxItemMessage,
+		ID:        message.PrivateMessage.ID,
+		Author:    message.Creator,
+		Title:     "Private message",
+		Content:   message.PrivateMessage.Content,
+		Published: message.PrivateMessage.Published,
+		Read:      message.PrivateMessage.Read,
+	}
+}
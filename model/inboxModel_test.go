@@ -0,0 +1,52 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func inboxItemsAt(kind InboxItemKind, hours ...int) []InboxItem {
+	items := make([]InboxItem, len(hours))
+	for idx, hour := range hours {
+		items[idx] = InboxItem{Kind: kind, ID: int64(hour), Published: time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC)}
+	}
+	return items
+}
+
+func TestInboxMergesSourcesAcrossPages(t *testing.T) {
+	var im InboxModel
+	im.CleanInbox(false)
+
+	if kinds := im.sourcesToRetrieve(); len(kinds) != 3 {
+		t.Fatalf("%d sources to retrieve at first, expected 3", len(kinds))
+	}
+	im.addSourceItems(InboxItemReply, inboxItemsAt(InboxItemReply, 23, 20, 10), 1, true)
+	im.addSourceItems(InboxItemMention, inboxItemsAt(InboxItemMention, 22), 1, true)
+	im.addSourceItems(InboxItemMessage, inboxItemsAt(InboxItemMessage, 21, 15), 1, false)
+
+	// Replies older than 15:00 have to wait for the next page of messages.
+	assertInboxOrder(t, im.mergeSources(20), 23, 22, 21, 20, 15)
+	kinds := im.sourcesToRetrieve()
+	if len(kinds) != 1 || kinds[0] != InboxItemMessage {
+		t.Fatalf("sources to retrieve are %v, expected only messages", kinds)
+	}
+
+	im.addSourceItems(InboxItemMessage, inboxItemsAt(InboxItemMessage, 12, 5), 2, true)
+	assertInboxOrder(t, im.mergeSources(2), 12, 10)
+	assertInboxOrder(t, im.mergeSources(20), 5)
+	if !im.isExhausted() {
+		t.Error("inbox isn't exhausted after merging every item")
+	}
+}
+
+func assertInboxOrder(t *testing.T, items []InboxItem, hours ...int) {
+	t.Helper()
+	if len(items) != len(hours) {
+		t.Fatalf("got %d items, expected %d", len(items), len(hours))
+	}
+	for idx, item := range items {
+		if item.Published.Hour() != hours[idx] {
+			t.Errorf("item %d was published at %d:00, expected %d:00", idx, item.Published.Hour(), hours[idx])
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gotk3/gotk3/gdk"
+	"go.elara.ws/go-lemmy"
+)
+
+// InstanceModel describes a Lemmy instance as seen before logging into it.
+type InstanceModel struct {
+	URL                 string
+	Name                string
+	Version             string
+	Icon                *gdk.Pixbuf
+	RegistrationMode    lemmy.RegistrationMode
+	CaptchaEnabled      bool
+	ApplicationQuestion string
+	LegalInformation    string
+	RequireEmail        bool
+}
+
+type CaptchaModel struct {
+	UUID  string
+	Image *gdk.Pixbuf
+}
+
+type Registration struct {
+	Username       string
+	Email          string
+	Password       string
+	PasswordVerify string
+	Answer         string
+	CaptchaUUID    string
+	CaptchaAnswer  string
+	ShowNSFW       bool
+}
+
+func (im *InstanceModel) RegistrationOpen() bool {
+	return im.RegistrationMode != lemmy.RegistrationModeClosed
+}
+
+func (im *InstanceModel) RequiresApplication() bool {
+	return im.RegistrationMode == lemmy.RegistrationModeRequireApplication
+}
+
+func newInstanceModel(instanceURL string, site *lemmy.GetSiteResponse) InstanceModel {
+	localSite := site.SiteView.LocalSite
+	return InstanceModel{
+		URL:                 instanceURL,
+		Name:                site.SiteView.Site.Name,
+		Version:             site.Version,
+		RegistrationMode:    localSite.RegistrationMode,
+		CaptchaEnabled:      localSite.CaptchaEnabled,
+		ApplicationQuestion: localSite.ApplicationQuestion.ValueOrZero(),
+		LegalInformation:    localSite.LegalInformation.ValueOrZero(),
+		RequireEmail:        localSite.RequireEmailVerification,
+	}
+}
+
+// NormalizeInstanceURL accepts what people usually type ("lemmy.ml",
+// "https://lemmy.ml/") and returns the base URL the client expects.
+func NormalizeInstanceURL(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", fmt.Errorf("Server URL is empty")
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	instanceURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("Invalid server URL: %s", err)
+	}
+	if instanceURL.Scheme != "https" && instanceURL.Scheme != "http" {
+		return "", fmt.Errorf("Unsupported server URL scheme %q", instanceURL.Scheme)
+	}
+	if instanceURL.Host == "" {
+		return "", fmt.Errorf("Server URL has no host")
+	}
+
+	return instanceURL.Scheme + "://" + instanceURL.Host, nil
+}
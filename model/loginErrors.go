@@ -0,0 +1,77 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.elara.ws/go-lemmy"
+)
+
+var (
+	ErrIncorrectLogin      = errors.New("incorrect username or password")
+	ErrMissingTOTP         = errors.New("a two-factor authentication code is required")
+	ErrIncorrectTOTP       = errors.New("incorrect two-factor authentication code")
+	ErrEmailNotVerified    = errors.New("email address not verified yet")
+	ErrRegistrationPending = errors.New("registration application still pending")
+	ErrRegistrationDenied  = errors.New("registration application denied")
+	ErrRegistrationClosed  = errors.New("registration is closed")
+	ErrCaptchaIncorrect    = errors.New("incorrect captcha answer")
+	ErrPasswordsDontMatch  = errors.New("passwords don't match")
+	ErrUsernameTaken       = errors.New("username already taken")
+	ErrAnswerRequired      = errors.New("the application question needs an answer")
+	ErrEmailRequired       = errors.New("an email address is required")
+	ErrSessionExpired      = errors.New("session expired, please log in again")
+)
+
+// loginError translates the error codes Lemmy answers a login or a
+// registration with into the errors above, so callers can tell the user what
+// to do next.
+func loginError(err error) error {
+	var lemmyError lemmy.Error
+	if !errors.As(err, &lemmyError) {
+		return err
+	}
+
+	switch lemmyError.ErrStr {
+	case "incorrect_login":
+		return ErrIncorrectLogin
+	case "missing_totp_token":
+		return ErrMissingTOTP
+	case "incorrect_totp_token":
+		return ErrIncorrectTOTP
+	case "email_not_verified":
+		return ErrEmailNotVerified
+	case "registration_application_pending", "registration_application_is_pending":
+		return ErrRegistrationPending
+	case "registration_denied":
+		return ErrRegistrationDenied
+	case "registration_closed":
+		return ErrRegistrationClosed
+	case "captcha_incorrect":
+		return ErrCaptchaIncorrect
+	case "passwords_dont_match":
+		return ErrPasswordsDontMatch
+	case "user_already_exists":
+		return ErrUsernameTaken
+	case "registration_application_answer_required":
+		return ErrAnswerRequired
+	case "email_required":
+		return ErrEmailRequired
+	}
+	return err
+}
+
+// sessionError marks the errors caused by a token the server doesn't accept
+// anymore, which only logging in again can fix.
+func sessionError(err error) error {
+	var lemmyError lemmy.Error
+	if !errors.As(err, &lemmyError) {
+		return err
+	}
+
+	if lemmyError.Code == http.StatusUnauthorized || lemmyError.ErrStr == "not_logged_in" {
+		return fmt.Errorf("%w (%s)", ErrSessionExpired, lemmyError)
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"slices"
+
+	"go.elara.ws/go-lemmy"
+)
+
+type MessagesModel struct {
+	Conversations       map[int64]*ConversationModel
+	NewConversations    func()
+	ConversationChanged func(int64)
+
+	nextPageToRetrieve int64
+	processID          int64
+	retrieving         bool
+	exhausted          bool
+}
+
+func (mm *MessagesModel) CleanMessages() {
+	mm.Conversations = make(map[int64]*ConversationModel)
+	mm.nextPageToRetrieve = 0
+	mm.processID++
+	mm.retrieving = false
+	mm.exhausted = false
+}
+
+func (mm *MessagesModel) SortedConversations() []*ConversationModel {
+	conversations := make([]*ConversationModel, 0, len(mm.Conversations))
+	for _, conversation := range mm.Conversations {
+		conversations = append(conversations, conversation)
+	}
+
+	slices.SortFunc(conversations, func(a *ConversationModel, b *ConversationModel) int {
+		lastA, okA := a.LastMessage()
+		lastB, okB := b.LastMessage()
+		switch {
+		case !okA && !okB:
+			return 0
+		case !okA:
+			return -1
+		case !okB:
+			return 1
+		}
+		return lastB.PrivateMessage.Published.Compare(lastA.PrivateMessage.Published)
+	})
+	return conversations
+}
+
+func (mm *MessagesModel) EnsureConversation(counterpart lemmy.Person) *ConversationModel {
+	conversation, ok := mm.Conversations[counterpart.ID]
+	if !ok {
+		conversation = &ConversationModel{Counterpart: counterpart}
+		mm.Conversations[counterpart.ID] = conversation
+	}
+	return conversation
+}
+
+func (mm *MessagesModel) addMessage(myUserID int64, message lemmy.PrivateMessageView) int64 {
+	counterpart := message.Creator
+	if message.Creator.ID == myUserID {
+		counterpart = message.Recipient
+	}
+
+	mm.EnsureConversation(counterpart).upsertMessage(message)
+	return counterpart.ID
+}
+
+func (mm *MessagesModel) signalNewConversations() {
+	if mm.NewConversations != nil {
+		mm.NewConversations()
+	}
+}
+
+func (mm *MessagesModel) signalConversationChanged(counterpartID int64) {
+	if mm.ConversationChanged != nil {
+		mm.ConversationChanged(counterpartID)
+	}
+}
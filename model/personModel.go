@@ -0,0 +1,39 @@
+package model
+
+import (
+	"context"
+	"log"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/mjdiliscia/LemmeRead/utils"
+	"go.elara.ws/go-lemmy"
+)
+
+type PersonModel struct {
+	lemmy.PersonView
+	Avatar *gdk.Pixbuf
+}
+
+func (pm *PersonModel) Init(ctx context.Context, callback func(error)) {
+	var taskSequence *utils.TaskSequence[*gdk.Pixbuf]
+	taskSequence = utils.NewTaskSequence[*gdk.Pixbuf](func() {
+		taskSequence = nil
+		callback(nil)
+	})
+
+	taskSequence.Add(func() (*gdk.Pixbuf, error) {
+		if pm.Person.Avatar.IsValid() {
+			return utils.LoadPixmapFromUrl(ctx, pm.Person.Avatar.ValueOrZero())
+		}
+		return nil, nil
+	}, func(pixbuf *gdk.Pixbuf, err error) bool {
+		if err != nil {
+			log.Println(err)
+		} else {
+			pm.Avatar = pixbuf
+		}
+		return true
+	})
+
+	taskSequence.Execute()
+}
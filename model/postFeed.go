@@ -0,0 +1,77 @@
+package model
+
+import (
+	"context"
+	"slices"
+)
+
+type PostFeed struct {
+	NewPosts    func()
+	CommunityID int64
+
+	lastAddedPosts     []int64
+	lastAddedOnTop     bool
+	nextPageToRetrieve int64
+	pendingProcesses   []string
+	ctx                context.Context
+	cancel             context.CancelFunc
+}
+
+func (pf *PostFeed) CleanFeed() {
+	if pf.cancel != nil {
+		pf.cancel()
+		pf.ctx, pf.cancel = nil, nil
+	}
+	pf.nextPageToRetrieve = 0
+	pf.lastAddedPosts = make([]int64, 0)
+	pf.lastAddedOnTop = false
+	pf.pendingProcesses = make([]string, 0)
+}
+
+// requestContext returns the context the requests of the feed run in, derived
+// from parent and cancelled as soon as the feed is cleaned. A context left over
+// from a previous session is replaced.
+func (pf *PostFeed) requestContext(parent context.Context) context.Context {
+	if pf.ctx == nil || pf.ctx.Err() != nil {
+		if parent == nil {
+			parent = context.Background()
+		}
+		pf.ctx, pf.cancel = context.WithCancel(parent)
+	}
+	return pf.ctx
+}
+
+// ConsumeLastAddedPosts returns the posts added since the last call, and whether
+// they go on top of the ones already shown.
+func (pf *PostFeed) ConsumeLastAddedPosts() (postIDs []int64, onTop bool) {
+	postIDs = pf.lastAddedPosts
+	pf.lastAddedPosts = make([]int64, 0)
+	return postIDs, pf.lastAddedOnTop
+}
+
+func (pf *PostFeed) isRetrieving() bool {
+	return len(pf.pendingProcesses) > 0
+}
+
+func (pf *PostFeed) startProcess(processID string) {
+	pf.pendingProcesses = append(pf.pendingProcesses, processID)
+}
+
+func (pf *PostFeed) isProcessPending(processID string) bool {
+	return slices.Index(pf.pendingProcesses, processID) != -1
+}
+
+func (pf *PostFeed) endProcess(processID string) bool {
+	processIndex := slices.Index(pf.pendingProcesses, processID)
+	if processIndex == -1 {
+		return false
+	}
+	pf.pendingProcesses = append(pf.pendingProcesses[:processIndex], pf.pendingProcesses[processIndex+1:]...)
+	return true
+}
+
+func (pf *PostFeed) signalNewPosts() {
+	if pf.NewPosts != nil {
+		pf.NewPosts()
+	}
+}
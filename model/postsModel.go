@@ -1,42 +1,132 @@
 package model
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/url"
+	"path"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/gotk3/gotk3/gdk"
 	"github.com/mjdiliscia/LemmeRead/utils"
 	"go.elara.ws/go-lemmy"
 )
 
+// MediaType tells what the URL of a post points to.
+type MediaType int
+
+const (
+	MediaNone MediaType = iota
+	MediaLink
+	MediaImage
+	MediaVideo
+	// MediaUnknown is for URLs the server has to be asked about.
+	MediaUnknown
+)
+
+// Pages taking longer than this to answer are shown as plain links.
+const linkPreviewTimeout = 10 * time.Second
+
+var (
+	imageExtensions = []string{".jpg", ".jpeg", ".png", ".gif", ".webp", ".avif", ".bmp", ".svg"}
+	videoExtensions = []string{".mp4", ".webm", ".mkv", ".mov", ".m4v", ".ogv"}
+)
+
 type PostModel struct {
 	lemmy.PostView
-	IsImagePost   bool
-	Link          string
-	Image         *gdk.Pixbuf
-	CommunityIcon *gdk.Pixbuf
-	Comments      []*CommentModel
+	PostMedia
+	Comments []*CommentModel
+
+	commentHolder []*CommentModel
+}
 
-	commentHolder []CommentModel
+// PostMedia is what a post shows besides its text. Only Media and Link are
+// known from the start, the rest is loaded once the post is seen.
+type PostMedia struct {
+	Media       MediaType
+	Link        string
+	MediaLoaded bool
+	Image       *gdk.Pixbuf
+	// Animation is set for animated images, Image holding their first frame.
+	Animation *gdk.PixbufAnimation
+	// Preview describes the page of link posts, when something is known about it.
+	Preview       *utils.LinkPreview
+	PreviewIcon   *gdk.Pixbuf
+	CommunityIcon *gdk.Pixbuf
 }
 
 type PMData struct {
-	str    string
-	pixbuf *gdk.Pixbuf
+	str       string
+	pixbuf    *gdk.Pixbuf
+	animation *gdk.PixbufAnimation
+	preview   *utils.LinkPreview
+}
+
+func NewPostModel(post lemmy.PostView) PostModel {
+	postModel := PostModel{PostView: post}
+	postModel.inferMedia()
+	return postModel
+}
+
+// inferMedia guesses what the URL of the post points to from its extension
+// and what Lemmy found out about it, leaving the rest as MediaUnknown.
+func (pm *PostModel) inferMedia() {
+	if !pm.Post.URL.IsValid() {
+		pm.Media = MediaNone
+		pm.setLink()
+		return
+	}
+
+	extension := ""
+	isPictrs := false
+	if parsed, err := url.Parse(pm.Post.URL.ValueOrZero()); err == nil {
+		extension = strings.ToLower(path.Ext(parsed.Path))
+		isPictrs = strings.Contains(parsed.Path, "/pictrs/image/")
+	}
+
+	switch {
+	case slices.Contains(imageExtensions, extension):
+		pm.Media = MediaImage
+	case slices.Contains(videoExtensions, extension):
+		pm.Media = MediaVideo
+	case pm.Post.EmbedTitle.IsValid() || pm.Post.EmbedDescription.IsValid() || pm.Post.EmbedVideoURL.IsValid():
+		// Lemmy only finds those in web pages.
+		pm.Media = MediaLink
+	case isPictrs:
+		pm.Media = MediaImage
+	default:
+		pm.Media = MediaUnknown
+	}
+	pm.setLink()
+}
+
+// setLink offers the URL as a link unless the post shows it whole. Unknown
+// media is linked until it's known, in case it can't be loaded.
+func (pm *PostModel) setLink() {
+	if pm.Media == MediaNone || pm.Media == MediaImage {
+		pm.Link = ""
+	} else {
+		pm.Link = pm.Post.URL.ValueOrZero()
+	}
 }
 
-func (pm *PostModel) Init(callback func(error)) {
+// LoadMedia fetches the media of the post, asking the server what its URL is
+// only if that couldn't be inferred. Errors are logged and the media left out.
+func (pm *PostModel) LoadMedia(ctx context.Context, callback func()) {
 	var taskSequence *utils.TaskSequence[PMData]
 	taskSequence = utils.NewTaskSequence[PMData](func() {
 		taskSequence = nil
-		callback(nil)
+		pm.MediaLoaded = true
+		callback()
 	})
 
-	taskSequence.Add(pm.getMimetypeTask, pm.processMimetypeTask)
-	taskSequence.Add(pm.getPostImageTask(), pm.setImageTask)
-	taskSequence.Add(pm.getPixbufTask(pm.Community.Icon), pm.setCommunityIconTask)
+	taskSequence.Add(pm.getMimetypeTask(ctx), pm.processMimetypeTask)
+	taskSequence.Add(pm.getPostImageTask(ctx), pm.setImageTask)
+	taskSequence.Add(pm.getLinkPreviewTask(ctx), pm.setLinkPreviewTask)
+	taskSequence.Add(pm.getPixbufTask(ctx, pm.Community.Icon), pm.setCommunityIconTask)
 
 	taskSequence.Execute()
 }
@@ -52,20 +142,20 @@ func (pm *PostModel) AddComments(comments []lemmy.CommentView, err error) error
 	commentMap := make(map[string]*CommentModel, len(comments))
 	for _, comment := range comments {
 		log.Printf("Processing comment with path %s...", comment.Comment.Path)
-		if slices.IndexFunc(pm.commentHolder, func(c CommentModel) bool { return comment.Comment.ID == c.Comment.ID }) >= 0 {
+		if slices.IndexFunc(pm.commentHolder, func(c *CommentModel) bool { return comment.Comment.ID == c.Comment.ID }) >= 0 {
 			log.Printf("Comment %d already known, skipping.", comment.Comment.ID)
 			continue
 		}
 
-		pm.commentHolder = append(pm.commentHolder, CommentModel{CommentView: comment})
-		commentPtr := &pm.commentHolder[len(pm.commentHolder)-1]
+		commentPtr := &CommentModel{CommentView: comment}
+		pm.commentHolder = append(pm.commentHolder, commentPtr)
 		commentMap[comment.Comment.Path] = commentPtr
 
 		parent := strings.Replace(comment.Comment.Path, fmt.Sprintf(".%d", comment.Comment.ID), "", 1)
 		if parent == "0" {
 			log.Println("is root comment.")
 			pm.Comments = append(pm.Comments, commentPtr)
-		} else if parentComment, ok := commentMap[parent]; ok {
+		} else if parentComment := pm.findParentComment(commentMap, parent, commentPtr.ParentID()); parentComment != nil {
 			log.Println("is child comment.")
 			parentComment.ChildComments = append(parentComment.ChildComments, commentPtr)
 		} else {
@@ -76,41 +166,122 @@ func (pm *PostModel) AddComments(comments []lemmy.CommentView, err error) error
 	return nil
 }
 
-func (pm *PostModel) getMimetypeTask() (PMData, error) {
-	if pm.Post.URL.IsValid() {
-		mimetype, err := utils.GetUrlMimetype(pm.Post.URL.ValueOrZero())
-		if err != nil {
-			return PMData{}, err
+func (pm *PostModel) UpdateComment(comment lemmy.CommentView) *CommentModel {
+	commentModel := pm.FindComment(comment.Comment.ID)
+	if commentModel != nil {
+		commentModel.CommentView = comment
+	}
+	return commentModel
+}
+
+func (pm *PostModel) SetMyVote(score int64) {
+	previous := pm.MyVote.ValueOrZero()
+	pm.Counts.Score += score - previous
+	pm.Counts.Upvotes += boolToInt(score > 0) - boolToInt(previous > 0)
+	pm.Counts.Downvotes += boolToInt(score < 0) - boolToInt(previous < 0)
+	pm.MyVote = lemmy.NewOptional(score)
+}
+
+func (pm *PostModel) FindComment(commentID int64) *CommentModel {
+	return findComment(pm.Comments, commentID)
+}
+
+func (pm *PostModel) findParentComment(commentMap map[string]*CommentModel, parentPath string, parentID int64) *CommentModel {
+	if parentComment, ok := commentMap[parentPath]; ok {
+		return parentComment
+	}
+	return pm.FindComment(parentID)
+}
+
+func (pm *PostModel) getMimetypeTask(ctx context.Context) func() (PMData, error) {
+	return func() (PMData, error) {
+		if pm.Media == MediaUnknown {
+			mimetype, err := utils.GetUrlMimetype(ctx, pm.Post.URL.ValueOrZero())
+			if err != nil {
+				return PMData{}, err
+			}
+			return PMData{str: mimetype}, err
+		} else {
+			return PMData{}, nil
 		}
-		return PMData{str: mimetype}, err
-	} else {
-		return PMData{}, nil
 	}
 }
 
 func (pm *PostModel) processMimetypeTask(data PMData, err error) bool {
-	pm.IsImagePost = strings.Split(data.str, "/")[0] == "image"
-	if !pm.IsImagePost {
-		pm.Link = pm.Post.URL.ValueOrZero()
+	if pm.Media != MediaUnknown {
+		return true
+	}
+	if err != nil {
+		log.Printf("Couldn't find out what the URL of post %d is, leaving it as a link: %s", pm.Post.ID, err)
+	}
+
+	switch {
+	case strings.HasPrefix(data.str, "image/"):
+		pm.Media = MediaImage
+	case strings.HasPrefix(data.str, "video/"):
+		pm.Media = MediaVideo
+	default:
+		pm.Media = MediaLink
 	}
+	pm.setLink()
 	return true
 }
 
-func (pm *PostModel) getPostImageTask() func() (PMData, error) {
-	var url lemmy.Optional[string]
-	if pm.IsImagePost {
-		url = pm.Post.URL
-	} else {
-		url = pm.Post.ThumbnailURL
+// getPostImageTask waits until running to choose what to load, as the media
+// type may not be known until the mimetype task is done.
+func (pm *PostModel) getPostImageTask(ctx context.Context) func() (PMData, error) {
+	return func() (PMData, error) {
+		if pm.Media != MediaImage {
+			return pm.getPixbufTask(ctx, pm.Post.ThumbnailURL)()
+		}
+
+		animation, pixbuf, err := utils.LoadAnimationFromUrl(ctx, pm.Post.URL.ValueOrZero())
+		if err != nil && pm.Post.ThumbnailURL.IsValid() && ctx.Err() == nil {
+			log.Printf("Couldn't load the image of post %d, using its thumbnail: %s", pm.Post.ID, err)
+			return pm.getPixbufTask(ctx, pm.Post.ThumbnailURL)()
+		}
+		return PMData{pixbuf: pixbuf, animation: animation}, err
 	}
+}
+
+// getLinkPreviewTask prefers what Lemmy already knows about the link, only
+// asking the page itself when Lemmy knows nothing. The favicon is loaded here
+// too, as it depends on the page.
+func (pm *PostModel) getLinkPreviewTask(ctx context.Context) func() (PMData, error) {
+	return func() (PMData, error) {
+		if pm.Media != MediaLink {
+			return PMData{}, nil
+		}
+
+		var preview utils.LinkPreview
+		if pm.Post.EmbedTitle.IsValid() || pm.Post.EmbedDescription.IsValid() {
+			preview = utils.NewLinkPreview(pm.Link, pm.Post.EmbedTitle.ValueOrZero(), pm.Post.EmbedDescription.ValueOrZero(), pm.Post.EmbedVideoURL.ValueOrZero())
+		} else {
+			pageCtx, cancel := context.WithTimeout(ctx, linkPreviewTimeout)
+			defer cancel()
+			var err error
+			preview, err = utils.FetchLinkPreview(pageCtx, pm.Link)
+			if err != nil {
+				return PMData{}, err
+			}
+		}
 
-	return pm.getPixbufTask(url)
+		data := PMData{preview: &preview}
+		if preview.FaviconURL != "" {
+			favicon, err := utils.LoadPixmapFromUrl(ctx, preview.FaviconURL)
+			if err != nil {
+				log.Printf("No favicon for the link of post %d: %s", pm.Post.ID, err)
+			}
+			data.pixbuf = favicon
+		}
+		return data, nil
+	}
 }
 
-func (pm *PostModel) getPixbufTask(url lemmy.Optional[string]) func() (PMData, error) {
+func (pm *PostModel) getPixbufTask(ctx context.Context, url lemmy.Optional[string]) func() (PMData, error) {
 	return func() (PMData, error) {
 		if url.IsValid() {
-			pixbuf, err := utils.LoadPixmapFromUrl(url.ValueOrZero())
+			pixbuf, err := utils.LoadPixmapFromUrl(ctx, url.ValueOrZero())
 			pmdata := PMData{pixbuf: pixbuf}
 			return pmdata, err
 		} else {
@@ -124,6 +295,17 @@ func (pm *PostModel) setImageTask(data PMData, err error) bool {
 		log.Println(err)
 	} else {
 		pm.Image = data.pixbuf
+		pm.Animation = data.animation
+	}
+	return true
+}
+
+func (pm *PostModel) setLinkPreviewTask(data PMData, err error) bool {
+	if err != nil {
+		log.Printf("No preview for the link of post %d: %s", pm.Post.ID, err)
+	} else {
+		pm.Preview = data.preview
+		pm.PreviewIcon = data.pixbuf
 	}
 	return true
 }
@@ -0,0 +1,38 @@
+package model
+
+type ProfileModel struct {
+	PostFeed
+	Person      PersonModel
+	PersonID    int64
+	NewPerson   func()
+	NewComments func()
+
+	lastAddedComments []CommentModel
+	exhausted         bool
+}
+
+func (pm *ProfileModel) CleanProfile(personID int64) {
+	pm.CleanFeed()
+	pm.Person = PersonModel{}
+	pm.PersonID = personID
+	pm.lastAddedComments = make([]CommentModel, 0)
+	pm.exhausted = false
+}
+
+func (pm *ProfileModel) ConsumeLastAddedComments() []CommentModel {
+	comments := pm.lastAddedComments
+	pm.lastAddedComments = make([]CommentModel, 0)
+	return comments
+}
+
+func (pm *ProfileModel) signalNewPerson() {
+	if pm.NewPerson != nil {
+		pm.NewPerson()
+	}
+}
+
+func (pm *ProfileModel) signalNewComments() {
+	if pm.NewComments != nil {
+		pm.NewComments()
+	}
+}
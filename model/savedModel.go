@@ -0,0 +1,27 @@
+package model
+
+type SavedModel struct {
+	PostFeed
+	NewComments func()
+
+	lastAddedComments []CommentModel
+	exhausted         bool
+}
+
+func (sm *SavedModel) CleanSaved() {
+	sm.CleanFeed()
+	sm.lastAddedComments = make([]CommentModel, 0)
+	sm.exhausted = false
+}
+
+func (sm *SavedModel) ConsumeLastAddedComments() []CommentModel {
+	comments := sm.lastAddedComments
+	sm.lastAddedComments = make([]CommentModel, 0)
+	return comments
+}
+
+func (sm *SavedModel) signalNewComments() {
+	if sm.NewComments != nil {
+		sm.NewComments()
+	}
+}
@@ -0,0 +1,35 @@
+package model
+
+type SearchModel struct {
+	PostFeed
+	Query      string
+	NewResults func()
+
+	exhausted            bool
+	lastAddedComments    []CommentModel
+	lastAddedUsers       []PersonModel
+	lastAddedCommunities []CommunityModel
+}
+
+func (sm *SearchModel) CleanSearch(query string) {
+	sm.CleanFeed()
+	sm.Query = query
+	sm.exhausted = false
+	sm.lastAddedComments = make([]CommentModel, 0)
+	sm.lastAddedUsers = make([]PersonModel, 0)
+	sm.lastAddedCommunities = make([]CommunityModel, 0)
+}
+
+func (sm *SearchModel) ConsumeLastResults() (communities []CommunityModel, users []PersonModel, comments []CommentModel) {
+	communities, users, comments = sm.lastAddedCommunities, sm.lastAddedUsers, sm.lastAddedComments
+	sm.lastAddedCommunities = make([]CommunityModel, 0)
+	sm.lastAddedUsers = make([]PersonModel, 0)
+	sm.lastAddedComments = make([]CommentModel, 0)
+	return
+}
+
+func (sm *SearchModel) signalNewResults() {
+	if sm.NewResults != nil {
+		sm.NewResults()
+	}
+}
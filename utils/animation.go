@@ -0,0 +1,18 @@
+package utils
+
+// #cgo pkg-config: gdk-pixbuf-2.0
+// #include <gdk-pixbuf/gdk-pixbuf.h>
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/gotk3/gotk3/gdk"
+)
+
+// isAnimated tells animations apart from still images, which the loader also
+// hands out as single frame animations.
+func isAnimated(animation *gdk.PixbufAnimation) bool {
+	native := (*C.GdkPixbufAnimation)(unsafe.Pointer(animation.GObject))
+	return C.gdk_pixbuf_animation_is_static_image(native) == 0
+}
@@ -5,7 +5,6 @@ import (
 	"log"
 	"math"
 	"reflect"
-	"regexp"
 	"time"
 
 	"github.com/gotk3/gotk3/gdk"
@@ -76,6 +75,30 @@ func ApplyStyle(widget *gtk.Widget) {
 	context.AddProvider(cssProvider, gtk.STYLE_PROVIDER_PRIORITY_APPLICATION)
 }
 
+func Confirm(widget *gtk.Widget, message string) bool {
+	var parent gtk.IWindow
+	if toplevel, err := widget.GetToplevel(); err == nil {
+		parent, _ = toplevel.(gtk.IWindow)
+	}
+
+	dialog := gtk.MessageDialogNew(parent, gtk.DIALOG_MODAL|gtk.DIALOG_DESTROY_WITH_PARENT, gtk.MESSAGE_QUESTION, gtk.BUTTONS_YES_NO, "%s", message)
+	defer dialog.Destroy()
+
+	return dialog.Run() == gtk.RESPONSE_YES
+}
+
+func ShowError(widget *gtk.Widget, message string) {
+	var parent gtk.IWindow
+	if toplevel, err := widget.GetToplevel(); err == nil {
+		parent, _ = toplevel.(gtk.IWindow)
+	}
+
+	dialog := gtk.MessageDialogNew(parent, gtk.DIALOG_MODAL|gtk.DIALOG_DESTROY_WITH_PARENT, gtk.MESSAGE_ERROR, gtk.BUTTONS_OK, "%s", message)
+	defer dialog.Destroy()
+
+	dialog.Run()
+}
+
 func GetNiceDuration(timestamp time.Duration) string {
 	switch {
 	case timestamp.Hours() > 24*365:
@@ -94,31 +117,3 @@ func GetNiceDuration(timestamp time.Duration) string {
 		return fmt.Sprintf("%ds", int(timestamp.Seconds()))
 	}
 }
-
-func MarkdownToLabelMarkup(text string) (markup string) {
-	// Bold conversion
-	boldRe := regexp.MustCompile(`\*\*(.+?)\*\*`)
-	markup = boldRe.ReplaceAllString(text, fmt.Sprintf("<b>%s</b>", "$1"))
-
-	// Italic conversion
-	italicRe := regexp.MustCompile(`\_(.+?)\_`)
-	markup = italicRe.ReplaceAllString(markup, fmt.Sprintf("<i>%s</i>", "$1"))
-
-	// Strikethrough conversion (basic)
-	strikethroughRe := regexp.MustCompile(`~~(.+?)~~`)
-	markup = strikethroughRe.ReplaceAllString(markup, fmt.Sprintf("<span style=\"text-decoration: line-through\">%s</span>", "$1"))
-
-	// Link conversion
-	linkRe := regexp.MustCompile(`\[(.+?)\]\((.+?)\)`)
-	markup = linkRe.ReplaceAllString(markup, fmt.Sprintf("<a href=\"%s\">%s</a>", "$2", "$1"))
-
-	// Textless link conversion
-	textlessLinkRe := regexp.MustCompile(`!\[\]\((.+?)\)`)
-	markup = textlessLinkRe.ReplaceAllString(markup, fmt.Sprintf("<a href=\"%s\">%s</a>", "$1", "$1"))
-
-	// & correction
-	ampersandRe := regexp.MustCompile(`\&`)
-	markup = ampersandRe.ReplaceAllString(markup, "&amp;")
-
-	return
-}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	userAgent             = "LemmeRead (+https://github.com/mjdiliscia/LemmeRead)"
+	requestTimeout        = 60 * time.Second
+	dialTimeout           = 10 * time.Second
+	tlsHandshakeTimeout   = 10 * time.Second
+	responseHeaderTimeout = 30 * time.Second
+	idleConnTimeout       = 90 * time.Second
+	maxIdleConnsPerHost   = 8
+)
+
+// httpClient is shared by the Lemmy API and the image downloads, so they all
+// reuse the same connections and honour the same proxy and timeouts.
+var httpClient = newHTTPClient(http.ProxyFromEnvironment)
+
+// SetupHTTPClient routes every request through proxy, or through the proxy
+// in the environment (HTTP_PROXY, HTTPS_PROXY and NO_PROXY) when it's empty.
+// It's meant to be called once, before any request is made.
+func SetupHTTPClient(proxy string) error {
+	if proxy == "" {
+		httpClient.Transport = newHTTPClient(http.ProxyFromEnvironment).Transport
+		return nil
+	}
+
+	proxyUrl, err := url.Parse(proxy)
+	if err != nil || proxyUrl.Host == "" {
+		return fmt.Errorf("Invalid proxy '%s'", proxy)
+	}
+	httpClient.Transport = newHTTPClient(http.ProxyURL(proxyUrl)).Transport
+	return nil
+}
+
+// HTTPClient returns the client every request of the application should use.
+func HTTPClient() *http.Client {
+	return httpClient
+}
+
+func newHTTPClient(proxy func(*http.Request) (*url.URL, error)) *http.Client {
+	transport := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   tlsHandshakeTimeout,
+		ResponseHeaderTimeout: responseHeaderTimeout,
+		IdleConnTimeout:       idleConnTimeout,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+	}
+
+	return &http.Client{
+		Transport: userAgentTransport{transport},
+		Timeout:   requestTimeout,
+	}
+}
+
+// userAgentTransport identifies the application in every request, as some
+// instances reject the default Go User-Agent.
+type userAgentTransport struct {
+	base http.RoundTripper
+}
+
+func (t userAgentTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Header.Get("User-Agent") == "" {
+		request = request.Clone(request.Context())
+		request.Header.Set("User-Agent", userAgent)
+	}
+	return t.base.RoundTrip(request)
+}
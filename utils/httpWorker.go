@@ -1,60 +1,192 @@
 package utils
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/gotk3/gotk3/gdk"
 )
 
-var httpCache map[string]*gdk.Pixbuf
+// LoadPixmapFromUrl is safe to call from any goroutine. It blocks until the
+// image is loaded, from the caches if possible, or until ctx is done. Only the
+// first frame of animated images is returned.
+func LoadPixmapFromUrl(ctx context.Context, url string) (*gdk.Pixbuf, error) {
+	image, err := loadCachedImage(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return image.pixbuf, nil
+}
 
-func LoadPixmapFromUrl(url string) (pixbuf *gdk.Pixbuf, err error) {
-	if httpCache == nil {
-		httpCache = make(map[string]*gdk.Pixbuf)
+// LoadAnimationFromUrl is like LoadPixmapFromUrl, but also returns the whole
+// animation when the image is animated, or nil if it isn't.
+func LoadAnimationFromUrl(ctx context.Context, url string) (*gdk.PixbufAnimation, *gdk.Pixbuf, error) {
+	image, err := loadCachedImage(ctx, url)
+	if err != nil {
+		return nil, nil, err
 	}
+	return image.animation, image.pixbuf, nil
+}
 
-	timestamp := time.Now()
-	pixbuf, ok := httpCache[url]
-	if !ok {
-		var response *http.Response
-		response, err = http.Get(url)
+// SaveImageFromUrl writes the image at url to filePath as it was downloaded,
+// without decoding it, taking it from the disk cache when it's there.
+func SaveImageFromUrl(ctx context.Context, url string, filePath string) error {
+	_, data := images.fromDisk(url)
+	if data == nil {
+		var err error
+		_, data, err = fetchImage(ctx, url, nil, nil)
 		if err != nil {
-			return
+			return err
 		}
+	}
 
-		if response.StatusCode != 200 {
-			return nil, fmt.Errorf("%s", response.Status)
-		}
+	return os.WriteFile(filePath, data, 0o644)
+}
 
-		var data []byte
-		data, err = io.ReadAll(response.Body)
-		if err != nil {
-			return
-		}
+func loadCachedImage(ctx context.Context, url string) (*memoryImage, error) {
+	timestamp := time.Now()
+	image, ok := images.fromMemory(url)
+	if ok {
+		log.Printf("CACHE time for '%s': %d", url, time.Now().Sub(timestamp).Milliseconds())
+		return image, nil
+	}
 
-		var loader *gdk.PixbufLoader
-		loader, err = gdk.PixbufLoaderNew()
-		if err != nil {
-			return
-		}
+	return fetcher.fetch(ctx, url)
+}
 
-		pixbuf, err = loader.WriteAndReturnPixbuf(data)
-		log.Printf("GET time for '%s': %d", url, time.Now().Sub(timestamp).Milliseconds())
+// loadImage runs in the fetcher workers, never twice at once for the same url.
+func loadImage(ctx context.Context, url string) (*memoryImage, error) {
+	timestamp := time.Now()
+	image, ok := images.fromMemory(url)
+	if ok {
+		return image, nil
+	}
 
-		httpCache[url] = pixbuf
+	metadata, data := images.fromDisk(url)
+	if metadata != nil && timestamp.Before(metadata.Expires) {
+		log.Printf("DISK time for '%s': %d", url, time.Now().Sub(timestamp).Milliseconds())
 	} else {
-		log.Printf("CACHE time for '%s': %d", url, time.Now().Sub(timestamp).Milliseconds())
+		var fetchErr error
+		metadata, data, fetchErr = fetchImage(ctx, url, metadata, data)
+		if fetchErr != nil && data == nil {
+			return nil, fetchErr
+		} else if fetchErr != nil {
+			log.Printf("Couldn't revalidate '%s', using the cached copy: %s", url, fetchErr)
+		} else {
+			log.Printf("GET time for '%s': %d", url, time.Now().Sub(timestamp).Milliseconds())
+		}
 	}
 
-	return
+	var err error
+	image = &memoryImage{url: url, expires: metadata.Expires}
+	image.pixbuf, image.animation, err = decodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+	images.remember(image)
+
+	return image, nil
 }
 
-func GetUrlMimetype(url string) (string, error) {
-	response, err := http.Head(url)
+// fetchImage downloads url, or just asks whether it changed when there's a
+// cached copy of it. On errors the cached copy is returned along the error.
+func fetchImage(ctx context.Context, url string, cached *imageMetadata, cachedData []byte) (*imageMetadata, []byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return cached, cachedData, err
+	}
+	if cached != nil && cached.ETag != "" {
+		request.Header.Set("If-None-Match", cached.ETag)
+	}
+	if cached != nil && cached.LastModified != "" {
+		request.Header.Set("If-Modified-Since", cached.LastModified)
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return cached, cachedData, err
+	}
+	defer response.Body.Close()
+
+	expires, store := cacheExpiry(response.Header, time.Now())
+	if response.StatusCode == http.StatusNotModified && cached != nil {
+		cached.Expires = expires
+		images.store(cached, nil)
+		return cached, cachedData, nil
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return cached, cachedData, fmt.Errorf("%s", response.Status)
+	}
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return cached, cachedData, err
+	}
+
+	metadata := &imageMetadata{
+		URL:          url,
+		ETag:         response.Header.Get("ETag"),
+		LastModified: response.Header.Get("Last-Modified"),
+		Expires:      expires,
+	}
+	if store {
+		images.store(metadata, data)
+	}
+	return metadata, data, nil
+}
+
+// decodeImage returns the first frame of data and, when it's animated, the
+// whole animation.
+func decodeImage(data []byte) (*gdk.Pixbuf, *gdk.PixbufAnimation, error) {
+	loader, err := gdk.PixbufLoaderNew()
+	if err != nil {
+		return nil, nil, err
+	}
+	animation, err := loader.WriteAndReturnPixbufAnimation(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	pixbuf, err := loader.GetPixbuf()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !isAnimated(animation) {
+		animation = nil
+	}
+	return pixbuf, animation, nil
+}
+
+func PixbufFromData(data []byte) (*gdk.Pixbuf, error) {
+	loader, err := gdk.PixbufLoaderNew()
+	if err != nil {
+		return nil, err
+	}
+	return loader.WriteAndReturnPixbuf(data)
+}
+
+func GetUrlMimetype(ctx context.Context, url string) (string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	response.Body.Close()
 
 	if response.StatusCode != 200 {
 		return "", fmt.Errorf("%s", response.Status)
@@ -62,3 +194,66 @@ func GetUrlMimetype(url string) (string, error) {
 
 	return response.Header.Get("Content-Type"), err
 }
+
+type pictrsUploadResponse struct {
+	Msg   string `json:"msg"`
+	Files []struct {
+		File        string `json:"file"`
+		DeleteToken string `json:"delete_token"`
+	} `json:"files"`
+}
+
+func UploadImage(ctx context.Context, server string, token string, filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("images[]", filepath.Base(filePath))
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(part, file); err != nil {
+		return "", err
+	}
+	if err = writer.Close(); err != nil {
+		return "", err
+	}
+
+	pictrsUrl, err := url.JoinPath(server, "pictrs", "image")
+	if err != nil {
+		return "", err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, pictrsUrl, &body)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.AddCookie(&http.Cookie{Name: "jwt", Value: token})
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return "", fmt.Errorf("%s", response.Status)
+	}
+
+	var uploadResponse pictrsUploadResponse
+	err = json.NewDecoder(response.Body).Decode(&uploadResponse)
+	if err != nil {
+		return "", err
+	}
+	if uploadResponse.Msg != "ok" || len(uploadResponse.Files) == 0 {
+		return "", fmt.Errorf("Image upload failed: %s", uploadResponse.Msg)
+	}
+
+	return url.JoinPath(pictrsUrl, uploadResponse.Files[0].File)
+}
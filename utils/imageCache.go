@@ -0,0 +1,314 @@
+package utils
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotk3/gotk3/gdk"
+)
+
+const (
+	maxMemoryImages     = 200
+	defaultImageMaxAge  = 24 * time.Hour
+	imageCacheTrimRatio = 0.9
+	imageMetadataSuffix = ".json"
+)
+
+// imageCache keeps the last decoded pixbufs in memory and the downloaded files
+// on disk, so images survive restarts without having to be fetched again.
+type imageCache struct {
+	mutex    sync.Mutex
+	dir      string
+	maxSize  int64
+	diskSize int64
+	lru      *list.List
+	memory   map[string]*list.Element
+}
+
+type memoryImage struct {
+	url       string
+	pixbuf    *gdk.Pixbuf
+	animation *gdk.PixbufAnimation
+	expires   time.Time
+}
+
+// imageMetadata is stored next to every cached file to know when it has to be
+// checked again and how to ask the server whether it changed.
+type imageMetadata struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Expires      time.Time `json:"expires"`
+}
+
+var images = &imageCache{lru: list.New(), memory: make(map[string]*list.Element)}
+
+// SetupImageCache enables the disk cache under dir, trimming it down to
+// maxSize bytes. Until it's called images are only cached in memory.
+func SetupImageCache(dir string, maxSize int64) error {
+	images.mutex.Lock()
+	defer images.mutex.Unlock()
+
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	images.dir = dir
+	images.maxSize = maxSize
+	images.diskSize = 0
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil {
+			images.diskSize += info.Size()
+		}
+	}
+
+	images.trim()
+	return nil
+}
+
+func SetImageCacheLimit(maxSize int64) {
+	images.mutex.Lock()
+	defer images.mutex.Unlock()
+
+	images.maxSize = maxSize
+	images.trim()
+}
+
+func ImageCacheSize() int64 {
+	images.mutex.Lock()
+	defer images.mutex.Unlock()
+
+	return images.diskSize
+}
+
+func ClearImageCache() error {
+	images.mutex.Lock()
+	defer images.mutex.Unlock()
+
+	images.lru.Init()
+	images.memory = make(map[string]*list.Element)
+	if images.dir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(images.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		err = errors.Join(err, os.Remove(filepath.Join(images.dir, entry.Name())))
+	}
+	images.diskSize = 0
+	return err
+}
+
+func (ic *imageCache) fromMemory(url string) (*memoryImage, bool) {
+	ic.mutex.Lock()
+	defer ic.mutex.Unlock()
+
+	element, ok := ic.memory[url]
+	if !ok {
+		return nil, false
+	}
+
+	image := element.Value.(*memoryImage)
+	if time.Now().After(image.expires) {
+		return nil, false
+	}
+	ic.lru.MoveToFront(element)
+	return image, true
+}
+
+func (ic *imageCache) remember(image *memoryImage) {
+	ic.mutex.Lock()
+	defer ic.mutex.Unlock()
+
+	if element, ok := ic.memory[image.url]; ok {
+		element.Value = image
+		ic.lru.MoveToFront(element)
+		return
+	}
+
+	ic.memory[image.url] = ic.lru.PushFront(image)
+	if ic.lru.Len() > maxMemoryImages {
+		oldest := ic.lru.Back()
+		ic.lru.Remove(oldest)
+		delete(ic.memory, oldest.Value.(*memoryImage).url)
+	}
+}
+
+// fromDisk returns the cached file for url, if any, even when it's stale: the
+// caller decides whether to revalidate it or to use it as it is.
+func (ic *imageCache) fromDisk(url string) (*imageMetadata, []byte) {
+	dataPath := ic.path(url)
+	if dataPath == "" {
+		return nil, nil
+	}
+
+	metadataFile, err := os.ReadFile(dataPath + imageMetadataSuffix)
+	if err != nil {
+		return nil, nil
+	}
+	var metadata imageMetadata
+	err = json.Unmarshal(metadataFile, &metadata)
+	if err != nil || metadata.URL != url {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(dataPath)
+	if err != nil {
+		return nil, nil
+	}
+
+	// Evicting by modification time makes this a least recently used cache.
+	now := time.Now()
+	os.Chtimes(dataPath, now, now)
+	os.Chtimes(dataPath+imageMetadataSuffix, now, now)
+	return &metadata, data
+}
+
+// store writes data, when not nil, and its metadata to disk.
+func (ic *imageCache) store(metadata *imageMetadata, data []byte) {
+	ic.mutex.Lock()
+	defer ic.mutex.Unlock()
+
+	dataPath := ic.path(metadata.URL)
+	if dataPath == "" {
+		return
+	}
+
+	metadataFile, err := json.Marshal(metadata)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if data != nil {
+		err = ic.writeFile(dataPath, data)
+	}
+	if err == nil {
+		err = ic.writeFile(dataPath+imageMetadataSuffix, metadataFile)
+	}
+	if err != nil {
+		log.Printf("Couldn't cache '%s': %s", metadata.URL, err)
+		return
+	}
+
+	ic.trim()
+}
+
+// writeFile replaces filePath atomically, keeping diskSize up to date.
+func (ic *imageCache) writeFile(filePath string, data []byte) error {
+	tempPath := filePath + ".tmp"
+	err := os.WriteFile(tempPath, data, 0600)
+	if err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(filePath); err == nil {
+		ic.diskSize -= info.Size()
+	}
+	err = os.Rename(tempPath, filePath)
+	if err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	ic.diskSize += int64(len(data))
+	return nil
+}
+
+// trim removes the least recently used files until the cache is comfortably
+// below its limit. It expects the mutex to be held.
+func (ic *imageCache) trim() {
+	if ic.dir == "" || ic.maxSize <= 0 || ic.diskSize <= ic.maxSize {
+		return
+	}
+
+	entries, err := os.ReadDir(ic.dir)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	infos := make([]fs.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil {
+			infos = append(infos, info)
+		}
+	}
+	slices.SortFunc(infos, func(a, b fs.FileInfo) int {
+		return a.ModTime().Compare(b.ModTime())
+	})
+
+	target := int64(float64(ic.maxSize) * imageCacheTrimRatio)
+	for _, info := range infos {
+		if ic.diskSize <= target {
+			break
+		}
+		err = os.Remove(filepath.Join(ic.dir, info.Name()))
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		ic.diskSize -= info.Size()
+	}
+	log.Printf("Image cache trimmed to %d bytes.", ic.diskSize)
+}
+
+func (ic *imageCache) path(url string) string {
+	if ic.dir == "" {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(url))
+	return filepath.Join(ic.dir, hex.EncodeToString(hash[:]))
+}
+
+// cacheExpiry tells until when a response can be used without asking the
+// server again, following its Cache-Control and Expires headers.
+func cacheExpiry(header http.Header, now time.Time) (expires time.Time, store bool) {
+	maxAge := time.Duration(-1)
+	noCache := false
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store":
+			return now, false
+		case directive == "no-cache":
+			noCache = true
+		case strings.HasPrefix(directive, "max-age="):
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+
+	switch {
+	case noCache:
+		return now, true
+	case maxAge >= 0:
+		return now.Add(maxAge), true
+	}
+
+	if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+		return expires, true
+	}
+	return now.Add(defaultImageMaxAge), true
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// useImageCache replaces the image cache with an empty one for the length of
+// the test, on disk under dir or only in memory if it's empty.
+func useImageCache(t *testing.T, dir string) *imageCache {
+	previous := images
+	images = &imageCache{dir: dir, maxSize: 1 << 30, lru: list.New(), memory: make(map[string]*list.Element)}
+	t.Cleanup(func() { images = previous })
+	return images
+}
+
+func testPNG(t *testing.T) []byte {
+	picture := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	picture.Set(0, 0, color.RGBA{R: 255, A: 255})
+
+	var data bytes.Buffer
+	err := png.Encode(&data, picture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data.Bytes()
+}
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := useImageCache(t, "")
+	expires := time.Now().Add(time.Hour)
+	for i := 0; i < maxMemoryImages; i++ {
+		cache.remember(&memoryImage{url: fmt.Sprintf("image%d", i), expires: expires})
+	}
+
+	// Using the oldest image leaves the next one as the least recently used.
+	if _, ok := cache.fromMemory("image0"); !ok {
+		t.Fatal("image0 isn't cached")
+	}
+	cache.remember(&memoryImage{url: "new", expires: expires})
+
+	for url, expected := range map[string]bool{"image0": true, "image1": false, "image2": true, "new": true} {
+		if _, ok := cache.fromMemory(url); ok != expected {
+			t.Errorf("%s cached: %v, expected %v", url, ok, expected)
+		}
+	}
+	if cache.lru.Len() != maxMemoryImages {
+		t.Errorf("%d images in memory, expected %d", cache.lru.Len(), maxMemoryImages)
+	}
+}
+
+func TestMemoryCacheExpires(t *testing.T) {
+	cache := useImageCache(t, "")
+	cache.remember(&memoryImage{url: "stale", expires: time.Now().Add(-time.Second)})
+
+	if _, ok := cache.fromMemory("stale"); ok {
+		t.Error("expired image was returned")
+	}
+}
+
+func TestDiskCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := useImageCache(t, t.TempDir())
+	data := make([]byte, 1000)
+	for _, url := range []string{"a", "b", "c"} {
+		cache.store(&imageMetadata{URL: url, Expires: time.Now().Add(time.Hour)}, data)
+	}
+
+	// Files are evicted by modification time, which reading them refreshes.
+	for age, url := range []string{"c", "b", "a"} {
+		past := time.Now().Add(-time.Duration(age+1) * time.Hour)
+		os.Chtimes(cache.path(url), past, past)
+		os.Chtimes(cache.path(url)+imageMetadataSuffix, past, past)
+	}
+	if metadata, _ := cache.fromDisk("a"); metadata == nil {
+		t.Fatal("a isn't cached")
+	}
+
+	// Going below the limit takes removing one image, the least recently used.
+	cache.mutex.Lock()
+	cache.maxSize = cache.diskSize - 500
+	cache.trim()
+	cache.mutex.Unlock()
+
+	for url, expected := range map[string]bool{"a": true, "b": false, "c": true} {
+		if metadata, _ := cache.fromDisk(url); (metadata != nil) != expected {
+			t.Errorf("%s cached: %v, expected %v", url, metadata != nil, expected)
+		}
+	}
+	if size := ImageCacheSize(); size > cache.maxSize {
+		t.Errorf("cache takes %d bytes, over its limit of %d", size, cache.maxSize)
+	}
+}
+
+func TestImageRevalidation(t *testing.T) {
+	useImageCache(t, t.TempDir())
+	data := testPNG(t)
+
+	var downloads, revalidations atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("ETag", `"v1"`)
+		writer.Header().Set("Cache-Control", "no-cache")
+		if request.Header.Get("If-None-Match") == `"v1"` {
+			revalidations.Add(1)
+			writer.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads.Add(1)
+		writer.Write(data)
+	}))
+	defer server.Close()
+	url := server.URL + "/image.png"
+
+	for i := 0; i < 3; i++ {
+		image, err := loadImage(context.Background(), url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if image.pixbuf == nil {
+			t.Fatal("image wasn't decoded")
+		}
+	}
+
+	if downloads.Load() != 1 || revalidations.Load() != 2 {
+		t.Errorf("%d downloads and %d revalidations, expected 1 and 2", downloads.Load(), revalidations.Load())
+	}
+	metadata, cached := images.fromDisk(url)
+	if metadata == nil || metadata.ETag != `"v1"` || !bytes.Equal(cached, data) {
+		t.Errorf("cached copy lost after revalidating: %+v", metadata)
+	}
+}
+
+func TestImageCacheConcurrentUse(t *testing.T) {
+	cache := useImageCache(t, t.TempDir())
+	expires := time.Now().Add(time.Hour)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				url := fmt.Sprintf("image%d", (worker+i)%10)
+				cache.remember(&memoryImage{url: url, expires: expires})
+				cache.fromMemory(url)
+				cache.store(&imageMetadata{URL: url, Expires: expires}, []byte(url))
+				cache.fromDisk(url)
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	for i := 0; i < 10; i++ {
+		url := fmt.Sprintf("image%d", i)
+		if _, data := cache.fromDisk(url); string(data) != url {
+			t.Errorf("%s cached as %q", url, data)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+const maxImageWorkers = 6
+
+// imageFetch is a download of one URL. Everyone asking for the URL while it's
+// in flight waits for the same imageFetch instead of starting another one, and
+// it's cancelled once none of them wants it anymore.
+type imageFetch struct {
+	url     string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+	done    chan struct{}
+	image   *memoryImage
+	err     error
+}
+
+// imageFetcher downloads and decodes images with a fixed number of workers,
+// however many posts are asking for them at once.
+type imageFetcher struct {
+	mutex    sync.Mutex
+	inFlight map[string]*imageFetch
+	slots    chan struct{}
+}
+
+var fetcher = &imageFetcher{
+	inFlight: make(map[string]*imageFetch),
+	slots:    make(chan struct{}, maxImageWorkers),
+}
+
+// fetch blocks until url is loaded or ctx is done, joining the download
+// already in flight for it if there's one.
+func (f *imageFetcher) fetch(ctx context.Context, url string) (*memoryImage, error) {
+	f.mutex.Lock()
+	job, ok := f.inFlight[url]
+	if !ok {
+		job = &imageFetch{url: url, done: make(chan struct{})}
+		job.ctx, job.cancel = context.WithCancel(context.Background())
+		f.inFlight[url] = job
+		go f.run(job)
+	}
+	job.waiters++
+	f.mutex.Unlock()
+
+	select {
+	case <-job.done:
+		return job.image, job.err
+	case <-ctx.Done():
+		f.leave(job)
+		return nil, ctx.Err()
+	}
+}
+
+// leave stops waiting for job, cancelling it when nobody else is.
+func (f *imageFetcher) leave(job *imageFetch) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	job.waiters--
+	if job.waiters > 0 {
+		return
+	}
+	job.cancel()
+	if f.inFlight[job.url] == job {
+		delete(f.inFlight, job.url)
+	}
+}
+
+func (f *imageFetcher) run(job *imageFetch) {
+	select {
+	case f.slots <- struct{}{}:
+		job.image, job.err = loadImage(job.ctx, job.url)
+		<-f.slots
+	case <-job.ctx.Done():
+		job.err = job.ctx.Err()
+	}
+
+	f.mutex.Lock()
+	if f.inFlight[job.url] == job {
+		delete(f.inFlight, job.url)
+	}
+	f.mutex.Unlock()
+	job.cancel()
+	close(job.done)
+}
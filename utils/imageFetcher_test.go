@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestFetcher() *imageFetcher {
+	return &imageFetcher{
+		inFlight: make(map[string]*imageFetch),
+		slots:    make(chan struct{}, maxImageWorkers),
+	}
+}
+
+// waitForWaiters blocks until waiters are waiting for url.
+func waitForWaiters(t *testing.T, f *imageFetcher, url string, waiters int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		f.mutex.Lock()
+		job, ok := f.inFlight[url]
+		joined := ok && job.waiters == waiters
+		f.mutex.Unlock()
+		if joined {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d waiters never joined the download of %s", waiters, url)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFetcherJoinsDownloadsOfSameUrl(t *testing.T) {
+	useImageCache(t, t.TempDir())
+	data := testPNG(t)
+
+	var requests atomic.Int32
+	release := make(chan struct{})
+	releaseOnce := sync.OnceFunc(func() { close(release) })
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests.Add(1)
+		<-release
+		writer.Write(data)
+	}))
+	defer server.Close()
+	defer releaseOnce()
+	url := server.URL + "/image.png"
+
+	const waiters = 8
+	f := newTestFetcher()
+	results := make([]*memoryImage, waiters)
+	errs := make([]error, waiters)
+	var wg sync.WaitGroup
+	for i := 0; i < waiters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = f.fetch(context.Background(), url)
+		}(i)
+	}
+
+	waitForWaiters(t, f, url, waiters)
+	releaseOnce()
+	wg.Wait()
+
+	if requests.Load() != 1 {
+		t.Errorf("%d requests for %d waiters, expected 1", requests.Load(), waiters)
+	}
+	for i := range results {
+		if errs[i] != nil {
+			t.Errorf("waiter %d failed: %s", i, errs[i])
+		} else if results[i] != results[0] {
+			t.Errorf("waiter %d got a different image", i)
+		}
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if len(f.inFlight) != 0 {
+		t.Errorf("%d downloads left in flight", len(f.inFlight))
+	}
+}
+
+func TestFetcherCancelsUnwantedDownloads(t *testing.T) {
+	useImageCache(t, t.TempDir())
+
+	cancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		<-request.Context().Done()
+		close(cancelled)
+	}))
+	defer server.Close()
+	url := server.URL + "/image.png"
+
+	f := newTestFetcher()
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	defer cancelFirst()
+	secondCtx, cancelSecond := context.WithCancel(context.Background())
+	defer cancelSecond()
+	errs := make(chan error, 2)
+	go func() {
+		_, err := f.fetch(firstCtx, url)
+		errs <- err
+	}()
+	go func() {
+		_, err := f.fetch(secondCtx, url)
+		errs <- err
+	}()
+	waitForWaiters(t, f, url, 2)
+
+	// The download goes on while someone still waits for it.
+	cancelFirst()
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("first waiter got %v, expected %v", err, context.Canceled)
+	}
+	waitForWaiters(t, f, url, 1)
+	select {
+	case <-cancelled:
+		t.Fatal("download cancelled while still wanted")
+	default:
+	}
+
+	cancelSecond()
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("second waiter got %v, expected %v", err, context.Canceled)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("download wasn't cancelled once nobody wanted it")
+	}
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+var (
+	kindSpoiler = ast.NewNodeKind("Spoiler")
+	kindScript  = ast.NewNodeKind("Script")
+
+	spoilerOpening = []byte(":::")
+	spoilerKeyword = []byte("spoiler")
+)
+
+// spoilerNode holds the blocks between "::: spoiler title" and ":::".
+type spoilerNode struct {
+	ast.BaseBlock
+	title string
+}
+
+func (n *spoilerNode) Kind() ast.NodeKind {
+	return kindSpoiler
+}
+
+func (n *spoilerNode) displayTitle() string {
+	if n.title == "" {
+		return defaultSpoiler
+	}
+	return n.title
+}
+
+func (n *spoilerNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Title": n.title}, nil)
+}
+
+type spoilerParser struct{}
+
+func (p spoilerParser) Trigger() []byte {
+	return []byte{':'}
+}
+
+func (p spoilerParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, _ := reader.PeekLine()
+	rest, ok := bytes.CutPrefix(bytes.TrimSpace(line), spoilerOpening)
+	if !ok {
+		return nil, parser.NoChildren
+	}
+	title, ok := bytes.CutPrefix(bytes.TrimSpace(rest), spoilerKeyword)
+	if !ok || (len(title) > 0 && title[0] != ' ' && title[0] != '\t') {
+		return nil, parser.NoChildren
+	}
+
+	reader.Advance(lineLength(line))
+	return &spoilerNode{title: string(bytes.TrimSpace(title))}, parser.HasChildren
+}
+
+func (p spoilerParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	line, _ := reader.PeekLine()
+	if bytes.Equal(bytes.TrimSpace(line), spoilerOpening) {
+		reader.Advance(lineLength(line))
+		return parser.Close
+	}
+	return parser.Continue | parser.HasChildren
+}
+
+func (p spoilerParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (p spoilerParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p spoilerParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// lineLength leaves the line break unread, so goldmark doesn't look for child
+// blocks in the next line before giving the parser a chance to continue.
+func lineLength(line []byte) int {
+	return len(bytes.TrimRight(line, "\r\n"))
+}
+
+// scriptNode is a ^superscript^ or a ~subscript~, tag being the Pango one.
+type scriptNode struct {
+	ast.BaseInline
+	tag string
+}
+
+func (n *scriptNode) Kind() ast.NodeKind {
+	return kindScript
+}
+
+func (n *scriptNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Tag": n.tag}, nil)
+}
+
+// scriptParser parses text between two delimiters as Lemmy does: on a single
+// line, not empty and without spaces. Double delimiters are left to the
+// strikethrough parser.
+type scriptParser struct {
+	delimiter byte
+	tag       string
+}
+
+func (p scriptParser) Trigger() []byte {
+	return []byte{p.delimiter}
+}
+
+func (p scriptParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	if len(line) < 3 || line[1] == p.delimiter {
+		return nil
+	}
+
+	end := bytes.IndexByte(line[1:], p.delimiter) + 1
+	if end <= 1 || bytes.ContainsAny(line[1:end], " \t\r\n") {
+		return nil
+	}
+
+	node := &scriptNode{tag: p.tag}
+	node.AppendChild(node, ast.NewTextSegment(text.NewSegment(segment.Start+1, segment.Start+end)))
+	block.Advance(end + 1)
+	return node
+}
@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Only the head of the page is read, and pages putting more than this before
+// their body aren't worth waiting for.
+const maxPreviewPageSize = 1 << 20
+
+// LinkPreview is what a page says about itself, for showing links as cards.
+type LinkPreview struct {
+	URL         string
+	SiteName    string
+	Title       string
+	Description string
+	VideoURL    string
+	FaviconURL  string
+}
+
+var (
+	previewsMutex sync.Mutex
+	previews      = make(map[string]LinkPreview)
+)
+
+// NewLinkPreview makes a preview out of what's already known about pageUrl,
+// guessing its site name and favicon from the URL.
+func NewLinkPreview(pageUrl string, title string, description string, videoUrl string) LinkPreview {
+	preview := LinkPreview{URL: pageUrl, Title: title, Description: description, VideoURL: videoUrl}
+	if parsed, err := url.Parse(pageUrl); err == nil && parsed.Host != "" {
+		preview.SiteName = strings.TrimPrefix(parsed.Hostname(), "www.")
+		preview.FaviconURL = (&url.URL{Scheme: parsed.Scheme, Host: parsed.Host, Path: "/favicon.ico"}).String()
+	}
+	return preview
+}
+
+// FetchLinkPreview reads the OpenGraph metadata of pageUrl, falling back to
+// the regular title and description of the page. Previews are remembered for
+// as long as the application runs.
+func FetchLinkPreview(ctx context.Context, pageUrl string) (LinkPreview, error) {
+	previewsMutex.Lock()
+	preview, ok := previews[pageUrl]
+	previewsMutex.Unlock()
+	if ok {
+		return preview, nil
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, pageUrl, nil)
+	if err != nil {
+		return LinkPreview{}, err
+	}
+	request.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return LinkPreview{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return LinkPreview{}, fmt.Errorf("%s", response.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return LinkPreview{}, fmt.Errorf("'%s' isn't a web page but %s", pageUrl, mediaType)
+	}
+
+	preview = NewLinkPreview(pageUrl, "", "", "")
+	// Relative URLs in the page are relative to where redirects ended.
+	parsePreview(io.LimitReader(response.Body, maxPreviewPageSize), response.Request.URL, &preview)
+	if preview.Title == "" && preview.Description == "" {
+		return LinkPreview{}, fmt.Errorf("'%s' has nothing to preview", pageUrl)
+	}
+
+	previewsMutex.Lock()
+	previews[pageUrl] = preview
+	previewsMutex.Unlock()
+
+	return preview, nil
+}
+
+// parsePreview fills preview with the metadata in the head of the page, where
+// OpenGraph properties win over Twitter cards, and those over plain HTML.
+func parsePreview(page io.Reader, base *url.URL, preview *LinkPreview) {
+	metadata := make(map[string]string)
+	var title, icon string
+	inTitle := false
+
+	tokenizer := html.NewTokenizer(page)
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		token := tokenizer.Token()
+
+		if (tokenType == html.EndTagToken && token.DataAtom == atom.Head) || token.DataAtom == atom.Body {
+			break
+		}
+		switch {
+		case tokenType == html.TextToken && inTitle:
+			title += token.Data
+		case token.DataAtom == atom.Title:
+			inTitle = tokenType == html.StartTagToken
+		case token.DataAtom == atom.Meta:
+			key := strings.ToLower(tokenAttribute(token, "property"))
+			if key == "" {
+				key = strings.ToLower(tokenAttribute(token, "name"))
+			}
+			if _, ok := metadata[key]; key != "" && !ok {
+				metadata[key] = strings.TrimSpace(tokenAttribute(token, "content"))
+			}
+		case token.DataAtom == atom.Link:
+			rel := strings.Fields(strings.ToLower(tokenAttribute(token, "rel")))
+			if icon == "" && (slices.Contains(rel, "icon") || slices.Contains(rel, "apple-touch-icon")) {
+				icon = tokenAttribute(token, "href")
+			}
+		}
+	}
+
+	first := func(values ...string) string {
+		for _, value := range values {
+			if value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+	preview.SiteName = first(metadata["og:site_name"], preview.SiteName)
+	preview.Title = first(metadata["og:title"], metadata["twitter:title"], strings.TrimSpace(title))
+	preview.Description = first(metadata["og:description"], metadata["twitter:description"], metadata["description"])
+	preview.VideoURL = resolveUrl(base, first(metadata["og:video:secure_url"], metadata["og:video:url"], metadata["og:video"]))
+	if icon != "" {
+		preview.FaviconURL = resolveUrl(base, icon)
+	}
+}
+
+func tokenAttribute(token html.Token, name string) string {
+	for _, attribute := range token.Attr {
+		if strings.EqualFold(attribute.Key, name) {
+			return attribute.Val
+		}
+	}
+	return ""
+}
+
+func resolveUrl(base *url.URL, reference string) string {
+	if reference == "" {
+		return ""
+	}
+	resolved, err := base.Parse(reference)
+	if err != nil {
+		return ""
+	}
+	return resolved.String()
+}
@@ -0,0 +1,396 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+const (
+	quotePrefix    = "│ "
+	spoilerPrefix  = "┃ "
+	cellSeparator  = " │ "
+	thematicBreak  = "――――――――――"
+	defaultSpoiler = "Spoiler"
+	spoilerScheme  = "spoiler:"
+)
+
+var (
+	headingMarkup = []string{
+		`<span size="x-large" weight="bold">%s</span>`,
+		`<span size="large" weight="bold">%s</span>`,
+		`<b>%s</b>`,
+	}
+	bullets = []string{"•", "◦", "▪"}
+)
+
+// markdown parses CommonMark plus the extensions Lemmy enables: strikethrough,
+// tables, bare links, spoilers, superscript and subscript.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.Strikethrough, extension.Table, extension.Linkify),
+	goldmark.WithParserOptions(
+		parser.WithBlockParsers(util.Prioritized(spoilerParser{}, 750)),
+		parser.WithInlineParsers(
+			util.Prioritized(scriptParser{delimiter: '^', tag: "sup"}, 450),
+			util.Prioritized(scriptParser{delimiter: '~', tag: "sub"}, 450),
+		),
+	),
+)
+
+// MarkdownBlock is either Pango markup, an image to show between the text or a
+// spoiler holding the blocks to show once it's opened.
+type MarkdownBlock struct {
+	Markup   string
+	ImageURL string
+	ImageAlt string
+
+	SpoilerTitle string
+	SpoilerIndex int
+	Spoiler      []MarkdownBlock
+}
+
+// ExpandedSpoilers tells which spoilers are open, numbered in the order they
+// appear in the markdown. Spoilers are closed unless told otherwise.
+type ExpandedSpoilers map[int]bool
+
+// SpoilerIndex tells which spoiler a link of the markup opens or closes, if
+// it's the title of one.
+func SpoilerIndex(uri string) (int, bool) {
+	number, ok := strings.CutPrefix(uri, spoilerScheme)
+	if !ok {
+		return 0, false
+	}
+	index, err := strconv.Atoi(number)
+	return index, err == nil
+}
+
+// MarkdownToLabelMarkup renders Lemmy's markdown as Pango markup suitable for
+// a GtkLabel. Labels have no blocks, so those are laid out with line breaks,
+// bullets and prefixes instead. Spoiler titles link to SpoilerIndex URIs, and
+// only the expanded ones show their content.
+func MarkdownToLabelMarkup(source string, expanded ExpandedSpoilers) string {
+	document, renderer := parseMarkdown(source, expanded)
+	return renderer.blocks(document, "\n\n")
+}
+
+// MarkdownToBlocks renders markdown like MarkdownToLabelMarkup, but splits the
+// paragraphs of the document around their images so they can be shown in
+// place, and the spoilers out of the text so they can be expanders. Images and
+// spoilers nested in lists, quotes or links are left in the markup.
+func MarkdownToBlocks(source string, expanded ExpandedSpoilers) []MarkdownBlock {
+	document, renderer := parseMarkdown(source, expanded)
+	return renderer.splitBlocks(document)
+}
+
+func parseMarkdown(source string, expanded ExpandedSpoilers) (ast.Node, *pangoRenderer) {
+	document := markdown.Parser().Parse(text.NewReader([]byte(source)))
+	renderer := &pangoRenderer{source: []byte(source), expanded: expanded, spoilers: make(map[*spoilerNode]int)}
+
+	ast.Walk(document, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if spoiler, ok := node.(*spoilerNode); ok && entering {
+			renderer.spoilers[spoiler] = len(renderer.spoilers)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	return document, renderer
+}
+
+type pangoRenderer struct {
+	source    []byte
+	listDepth int
+	expanded  ExpandedSpoilers
+	spoilers  map[*spoilerNode]int
+}
+
+func (r *pangoRenderer) blocks(parent ast.Node, separator string) string {
+	parts := make([]string, 0, parent.ChildCount())
+	for child := parent.FirstChild(); child != nil; child = child.NextSibling() {
+		if part := r.block(child); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, separator)
+}
+
+func (r *pangoRenderer) splitBlocks(parent ast.Node) (blocks []MarkdownBlock) {
+	var text []string
+	addText := func(markup string) {
+		if markup = strings.TrimSpace(markup); markup != "" {
+			text = append(text, markup)
+		}
+	}
+	flushText := func() {
+		if len(text) > 0 {
+			blocks = append(blocks, MarkdownBlock{Markup: strings.Join(text, "\n\n")})
+			text = nil
+		}
+	}
+
+	for child := parent.FirstChild(); child != nil; child = child.NextSibling() {
+		if spoiler, ok := child.(*spoilerNode); ok {
+			flushText()
+			blocks = append(blocks, MarkdownBlock{
+				SpoilerTitle: spoiler.displayTitle(),
+				SpoilerIndex: r.spoilers[spoiler],
+				Spoiler:      r.splitBlocks(spoiler),
+			})
+			continue
+		}
+
+		paragraph, ok := child.(*ast.Paragraph)
+		if !ok {
+			addText(r.block(child))
+			continue
+		}
+
+		var markup strings.Builder
+		for inline := paragraph.FirstChild(); inline != nil; inline = inline.NextSibling() {
+			image, ok := inline.(*ast.Image)
+			if !ok {
+				markup.WriteString(r.inline(inline))
+				continue
+			}
+
+			addText(markup.String())
+			markup.Reset()
+			flushText()
+			blocks = append(blocks, MarkdownBlock{
+				ImageURL: string(image.Destination),
+				ImageAlt: r.rawText(image),
+			})
+		}
+		addText(markup.String())
+	}
+	flushText()
+
+	return
+}
+
+func (r *pangoRenderer) block(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.Paragraph, *ast.TextBlock:
+		return r.inlines(n)
+	case *ast.Heading:
+		return fmt.Sprintf(headingMarkup[min(n.Level, len(headingMarkup))-1], r.inlines(n))
+	case *ast.ThematicBreak:
+		return thematicBreak
+	case *ast.CodeBlock, *ast.FencedCodeBlock:
+		return "<tt>" + html.EscapeString(r.lines(n)) + "</tt>"
+	case *ast.HTMLBlock:
+		// Like Lemmy, raw HTML is shown as it was written.
+		content := r.lines(n)
+		if n.HasClosure() {
+			content += "\n" + string(n.ClosureLine.Value(r.source))
+		}
+		return html.EscapeString(strings.TrimRight(content, "\n"))
+	case *ast.Blockquote:
+		return indentLines(r.blocks(n, "\n\n"), quotePrefix, quotePrefix)
+	case *ast.List:
+		return r.list(n)
+	case *extast.Table:
+		return r.table(n)
+	case *spoilerNode:
+		return r.spoiler(n)
+	default:
+		return r.blocks(n, "\n\n")
+	}
+}
+
+func (r *pangoRenderer) list(list *ast.List) string {
+	separator := "\n\n"
+	if list.IsTight {
+		separator = "\n"
+	}
+
+	bullet := bullets[r.listDepth%len(bullets)]
+	r.listDepth++
+	defer func() { r.listDepth-- }()
+
+	number := list.Start
+	items := make([]string, 0, list.ChildCount())
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := bullet
+		if list.IsOrdered() {
+			marker = fmt.Sprintf("%d%c", number, list.Marker)
+			number++
+		}
+		padding := strings.Repeat(" ", utf8.RuneCountInString(marker)+1)
+		items = append(items, indentLines(r.blocks(item, separator), marker+" ", padding))
+	}
+	return strings.Join(items, separator)
+}
+
+func (r *pangoRenderer) spoiler(spoiler *spoilerNode) string {
+	index := r.spoilers[spoiler]
+	arrow := "▸"
+	if r.expanded[index] {
+		arrow = "▾"
+	}
+	header := linkMarkup(spoilerScheme+strconv.Itoa(index), "", "<b>"+arrow+" "+html.EscapeString(spoiler.displayTitle())+"</b>")
+
+	if !r.expanded[index] {
+		return header
+	}
+	content := r.blocks(spoiler, "\n\n")
+	if content == "" {
+		return header
+	}
+	return header + "\n" + indentLines(content, spoilerPrefix, spoilerPrefix)
+}
+
+// table lays every row out in a line, as labels can't align columns.
+func (r *pangoRenderer) table(table *extast.Table) string {
+	rows := make([]string, 0, table.ChildCount())
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		cells := make([]string, 0, row.ChildCount())
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			cells = append(cells, strings.TrimSpace(r.inlines(cell)))
+		}
+
+		line := strings.Join(cells, cellSeparator)
+		if _, ok := row.(*extast.TableHeader); ok {
+			line = "<b>" + line + "</b>"
+		}
+		rows = append(rows, line)
+	}
+	return strings.Join(rows, "\n")
+}
+
+func (r *pangoRenderer) inlines(parent ast.Node) string {
+	var markup strings.Builder
+	for child := parent.FirstChild(); child != nil; child = child.NextSibling() {
+		markup.WriteString(r.inline(child))
+	}
+	return markup.String()
+}
+
+func (r *pangoRenderer) inline(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.Text:
+		markup := escapeMarkdownText(n.Segment.Value(r.source))
+		if n.HardLineBreak() {
+			markup += "\n"
+		} else if n.SoftLineBreak() {
+			markup += " "
+		}
+		return markup
+	case *ast.String:
+		if n.IsCode() || n.IsRaw() {
+			return html.EscapeString(string(n.Value))
+		}
+		return escapeMarkdownText(n.Value)
+	case *ast.CodeSpan:
+		code := strings.ReplaceAll(r.rawText(n), "\n", " ")
+		return "<tt>" + html.EscapeString(code) + "</tt>"
+	case *ast.Emphasis:
+		if n.Level >= 2 {
+			return "<b>" + r.inlines(n) + "</b>"
+		}
+		return "<i>" + r.inlines(n) + "</i>"
+	case *ast.Link:
+		label := r.inlines(n)
+		if label == "" {
+			label = html.EscapeString(string(n.Destination))
+		}
+		return linkMarkup(string(n.Destination), escapeMarkdownText(n.Title), label)
+	case *ast.AutoLink:
+		url := string(n.URL(r.source))
+		if n.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(strings.ToLower(url), "mailto:") {
+			url = "mailto:" + url
+		}
+		return linkMarkup(url, "", html.EscapeString(string(n.Label(r.source))))
+	case *ast.Image:
+		label := r.inlines(n)
+		if label == "" {
+			label = html.EscapeString(string(n.Destination))
+		}
+		// Pango can't nest links, so linked images only show their text.
+		if _, ok := n.Parent().(*ast.Link); ok {
+			return label
+		}
+		return linkMarkup(string(n.Destination), escapeMarkdownText(n.Title), label)
+	case *ast.RawHTML:
+		var raw strings.Builder
+		for i := 0; i < n.Segments.Len(); i++ {
+			segment := n.Segments.At(i)
+			raw.Write(segment.Value(r.source))
+		}
+		return html.EscapeString(raw.String())
+	case *extast.Strikethrough:
+		return "<s>" + r.inlines(n) + "</s>"
+	case *scriptNode:
+		return "<" + n.tag + ">" + r.inlines(n) + "</" + n.tag + ">"
+	default:
+		return r.inlines(n)
+	}
+}
+
+// lines returns the content of a block as it was written.
+func (r *pangoRenderer) lines(node ast.Node) string {
+	var content strings.Builder
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		content.Write(line.Value(r.source))
+	}
+	return strings.TrimRight(content.String(), "\n")
+}
+
+func (r *pangoRenderer) rawText(parent ast.Node) string {
+	var content strings.Builder
+	for child := parent.FirstChild(); child != nil; child = child.NextSibling() {
+		switch c := child.(type) {
+		case *ast.Text:
+			content.Write(c.Segment.Value(r.source))
+		case *ast.String:
+			content.Write(c.Value)
+		default:
+			content.WriteString(r.rawText(c))
+		}
+	}
+	return content.String()
+}
+
+// linkMarkup takes the title and label as markup already.
+func linkMarkup(url string, title string, label string) string {
+	if title != "" {
+		return fmt.Sprintf(`<a href="%s" title="%s">%s</a>`, html.EscapeString(url), title, label)
+	}
+	return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), label)
+}
+
+// escapeMarkdownText resolves backslash escapes and entity references, then
+// escapes whatever Pango would take as markup.
+func escapeMarkdownText(value []byte) string {
+	var escaped bytes.Buffer
+	writer := bufio.NewWriter(&escaped)
+	gmhtml.DefaultWriter.Write(writer, value)
+	writer.Flush()
+	return escaped.String()
+}
+
+func indentLines(text string, first string, rest string) string {
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		if i == 0 {
+			lines[i] = first + lines[i]
+		} else {
+			lines[i] = rest + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
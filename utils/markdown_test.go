@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+// The markdown tests render every testdata/markdown/*.md file and compare it
+// with the .label and .blocks golden files next to it.
+func TestMarkdownToLabelMarkup(t *testing.T) {
+	forEachMarkdownFile(t, ".label", func(source string) string {
+		return MarkdownToLabelMarkup(source, nil)
+	})
+}
+
+func TestMarkdownToBlocks(t *testing.T) {
+	forEachMarkdownFile(t, ".blocks", func(source string) string {
+		var dump strings.Builder
+		dumpBlocks(&dump, MarkdownToBlocks(source, nil), "")
+		return dump.String()
+	})
+}
+
+func TestExpandedSpoilers(t *testing.T) {
+	source := "::: spoiler Title\nHidden **text**\n:::"
+
+	collapsed := MarkdownToLabelMarkup(source, nil)
+	if expected := `<a href="spoiler:0"><b>▸ Title</b></a>`; collapsed != expected {
+		t.Errorf("collapsed spoiler is %q, expected %q", collapsed, expected)
+	}
+
+	expanded := MarkdownToLabelMarkup(source, ExpandedSpoilers{0: true})
+	if expected := "<a href=\"spoiler:0\"><b>▾ Title</b></a>\n┃ Hidden <b>text</b>"; expanded != expected {
+		t.Errorf("expanded spoiler is %q, expected %q", expanded, expected)
+	}
+}
+
+func TestSpoilerIndex(t *testing.T) {
+	if index, ok := SpoilerIndex("spoiler:12"); !ok || index != 12 {
+		t.Errorf("got spoiler %d (%v), expected 12", index, ok)
+	}
+	for _, uri := range []string{"https://lemmy.example/", "spoiler:", "spoiler:x"} {
+		if _, ok := SpoilerIndex(uri); ok {
+			t.Errorf("%q was taken as a spoiler", uri)
+		}
+	}
+}
+
+func forEachMarkdownFile(t *testing.T, extension string, render func(string) string) {
+	sources, err := filepath.Glob(filepath.Join("testdata", "markdown", "*.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) == 0 {
+		t.Fatal("no markdown files in testdata")
+	}
+
+	for _, sourcePath := range sources {
+		name := strings.TrimSuffix(filepath.Base(sourcePath), ".md")
+		t.Run(name, func(t *testing.T) {
+			source, err := os.ReadFile(sourcePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			output := render(string(source))
+
+			goldenPath := strings.TrimSuffix(sourcePath, ".md") + extension
+			if *update {
+				err = os.WriteFile(goldenPath, []byte(output), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			golden, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != string(golden) {
+				t.Errorf("output differs from %s:\n%s", goldenPath, output)
+			}
+		})
+	}
+}
+
+func dumpBlocks(dump *strings.Builder, blocks []MarkdownBlock, indent string) {
+	for _, block := range blocks {
+		switch {
+		case block.SpoilerTitle != "":
+			fmt.Fprintf(dump, "%sspoiler %d %q\n", indent, block.SpoilerIndex, block.SpoilerTitle)
+			dumpBlocks(dump, block.Spoiler, indent+"  ")
+		case block.ImageURL != "":
+			fmt.Fprintf(dump, "%simage %s %q\n", indent, block.ImageURL, block.ImageAlt)
+		default:
+			fmt.Fprintf(dump, "%smarkup\n", indent)
+			for _, line := range strings.Split(block.Markup, "\n") {
+				fmt.Fprintf(dump, "%s| %s\n", indent, line)
+			}
+		}
+	}
+}
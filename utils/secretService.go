@@ -0,0 +1,106 @@
+package utils
+
+// #cgo pkg-config: libsecret-1
+// #include <stdlib.h>
+// #include <libsecret/secret.h>
+//
+// static const SecretSchema *token_schema(void) {
+// 	static const SecretSchema schema = {
+// 		"io.github.mjdiliscia.lemmeread.Token", SECRET_SCHEMA_NONE,
+// 		{
+// 			{ "account", SECRET_SCHEMA_ATTRIBUTE_STRING },
+// 			{ "server", SECRET_SCHEMA_ATTRIBUTE_STRING },
+// 			{ NULL, 0 },
+// 		}
+// 	};
+// 	return &schema;
+// }
+//
+// static gboolean secret_service_available(void) {
+// 	GError *error = NULL;
+// 	SecretService *service = secret_service_get_sync(SECRET_SERVICE_OPEN_SESSION, NULL, &error);
+// 	if (service == NULL) {
+// 		g_clear_error(&error);
+// 		return FALSE;
+// 	}
+// 	g_object_unref(service);
+// 	return TRUE;
+// }
+//
+// static gchar *lookup_token(const gchar *account, GError **error) {
+// 	return secret_password_lookup_sync(token_schema(), NULL, error, "account", account, NULL);
+// }
+//
+// static gboolean store_token(const gchar *account, const gchar *server, const gchar *label, const gchar *token, GError **error) {
+// 	return secret_password_store_sync(token_schema(), SECRET_COLLECTION_DEFAULT, label, token, NULL, error,
+// 		"account", account, "server", server, NULL);
+// }
+//
+// static gboolean clear_token(const gchar *account, GError **error) {
+// 	return secret_password_clear_sync(token_schema(), NULL, error, "account", account, NULL);
+// }
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// SecretServiceAvailable reports whether a Secret Service provider (GNOME
+// Keyring, KWallet...) answers on the session bus.
+func SecretServiceAvailable() bool {
+	return C.secret_service_available() != 0
+}
+
+// LookupSecretToken returns the token stored for account, or "" if there is none.
+func LookupSecretToken(account string) (string, error) {
+	cAccount := C.CString(account)
+	defer C.free(unsafe.Pointer(cAccount))
+
+	var cError *C.GError
+	cToken := C.lookup_token((*C.gchar)(cAccount), &cError)
+	if cError != nil {
+		return "", takeGError(cError)
+	}
+	if cToken == nil {
+		return "", nil
+	}
+	defer C.secret_password_free(cToken)
+
+	return C.GoString((*C.char)(cToken)), nil
+}
+
+func StoreSecretToken(account string, server string, label string, token string) error {
+	cAccount := C.CString(account)
+	defer C.free(unsafe.Pointer(cAccount))
+	cServer := C.CString(server)
+	defer C.free(unsafe.Pointer(cServer))
+	cLabel := C.CString(label)
+	defer C.free(unsafe.Pointer(cLabel))
+	cToken := C.CString(token)
+	defer C.free(unsafe.Pointer(cToken))
+
+	var cError *C.GError
+	C.store_token((*C.gchar)(cAccount), (*C.gchar)(cServer), (*C.gchar)(cLabel), (*C.gchar)(cToken), &cError)
+	if cError != nil {
+		return takeGError(cError)
+	}
+	return nil
+}
+
+func ClearSecretToken(account string) error {
+	cAccount := C.CString(account)
+	defer C.free(unsafe.Pointer(cAccount))
+
+	var cError *C.GError
+	C.clear_token((*C.gchar)(cAccount), &cError)
+	if cError != nil {
+		return takeGError(cError)
+	}
+	return nil
+}
+
+func takeGError(cError *C.GError) error {
+	defer C.g_error_free(cError)
+	return errors.New(C.GoString((*C.char)(cError.message)))
+}
@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	secretServicePath      = dbus.ObjectPath("/org/freedesktop/secrets")
+	secretDefaultAliasPath = dbus.ObjectPath("/org/freedesktop/secrets/aliases/default")
+	secretSessionPath      = dbus.ObjectPath("/org/freedesktop/secrets/session/plain")
+	secretItemInterface    = "org.freedesktop.Secret.Item"
+)
+
+// mockSecretService implements the part of the Secret Service API libsecret
+// uses to store, look up and clear passwords, without encryption or locking.
+type mockSecretService struct {
+	conn   *dbus.Conn
+	mutex  sync.Mutex
+	items  map[dbus.ObjectPath]*mockSecretItem
+	nextID int
+}
+
+// mockSecret is the Secret structure of the API.
+type mockSecret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+type mockSecretItem struct {
+	service    *mockSecretService
+	path       dbus.ObjectPath
+	attributes map[string]string
+	secret     mockSecret
+}
+
+// mockSecretCollection is the default collection, the one tokens are stored in.
+type mockSecretCollection struct {
+	service *mockSecretService
+}
+
+// newMockSecretService serves a mock on the session bus under a name of its
+// own and points libsecret to it, so no real keyring is ever touched.
+func newMockSecretService(t *testing.T) *mockSecretService {
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		t.Skip("No session bus available")
+	}
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		t.Skipf("Couldn't connect to the session bus: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	name := fmt.Sprintf("io.github.mjdiliscia.lemmeread.test.Secrets%d", os.Getpid())
+	reply, err := conn.RequestName(name, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Skipf("Couldn't own %s on the session bus: %v", name, err)
+	}
+	t.Setenv("SECRET_SERVICE_BUS_NAME", name)
+
+	mss := &mockSecretService{conn: conn, items: make(map[dbus.ObjectPath]*mockSecretItem)}
+	err = conn.Export(mss, secretServicePath, "org.freedesktop.Secret.Service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = conn.Export(&mockSecretCollection{service: mss}, secretDefaultAliasPath, "org.freedesktop.Secret.Collection")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mss
+}
+
+func (mss *mockSecretService) OpenSession(algorithm string, input dbus.Variant) (dbus.Variant, dbus.ObjectPath, *dbus.Error) {
+	// libsecret asks for an encrypted session first and falls back to plain.
+	if algorithm != "plain" {
+		return dbus.Variant{}, "/", &dbus.Error{Name: "org.freedesktop.DBus.Error.NotSupported", Body: []interface{}{"Only plain sessions are supported"}}
+	}
+	return dbus.MakeVariant(""), secretSessionPath, nil
+}
+
+func (mss *mockSecretService) SearchItems(attributes map[string]string) ([]dbus.ObjectPath, []dbus.ObjectPath, *dbus.Error) {
+	mss.mutex.Lock()
+	defer mss.mutex.Unlock()
+
+	unlocked := []dbus.ObjectPath{}
+	for path, item := range mss.items {
+		if item.matches(attributes) {
+			unlocked = append(unlocked, path)
+		}
+	}
+	return unlocked, []dbus.ObjectPath{}, nil
+}
+
+func (mss *mockSecretService) GetSecrets(items []dbus.ObjectPath, session dbus.ObjectPath) (map[dbus.ObjectPath]mockSecret, *dbus.Error) {
+	mss.mutex.Lock()
+	defer mss.mutex.Unlock()
+
+	secrets := make(map[dbus.ObjectPath]mockSecret)
+	for _, path := range items {
+		if item, ok := mss.items[path]; ok {
+			secrets[path] = mockSecret{Session: session, Parameters: []byte{}, Value: item.secret.Value, ContentType: item.secret.ContentType}
+		}
+	}
+	return secrets, nil
+}
+
+func (mss *mockSecretService) itemCount() int {
+	mss.mutex.Lock()
+	defer mss.mutex.Unlock()
+	return len(mss.items)
+}
+
+func (msc *mockSecretCollection) CreateItem(properties map[string]dbus.Variant, secret mockSecret, replace bool) (dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	attributes, ok := properties["org.freedesktop.Secret.Item.Attributes"].Value().(map[string]string)
+	if !ok {
+		return "/", "/", dbus.MakeFailedError(fmt.Errorf("item without attributes"))
+	}
+
+	mss := msc.service
+	mss.mutex.Lock()
+	defer mss.mutex.Unlock()
+
+	if replace {
+		for path, item := range mss.items {
+			if len(item.attributes) == len(attributes) && item.matches(attributes) {
+				item.secret = secret
+				return path, "/", nil
+			}
+		}
+	}
+
+	mss.nextID++
+	item := &mockSecretItem{
+		service:    mss,
+		path:       dbus.ObjectPath(fmt.Sprintf("%s/collection/default/%d", secretServicePath, mss.nextID)),
+		attributes: attributes,
+		secret:     secret,
+	}
+	err := mss.conn.Export(item, item.path, secretItemInterface)
+	if err != nil {
+		return "/", "/", dbus.MakeFailedError(err)
+	}
+	mss.items[item.path] = item
+	return item.path, "/", nil
+}
+
+func (msi *mockSecretItem) Delete() (dbus.ObjectPath, *dbus.Error) {
+	mss := msi.service
+	mss.mutex.Lock()
+	defer mss.mutex.Unlock()
+
+	delete(mss.items, msi.path)
+	mss.conn.Export(nil, msi.path, secretItemInterface)
+	return "/", nil
+}
+
+func (msi *mockSecretItem) matches(attributes map[string]string) bool {
+	for name, value := range attributes {
+		if msi.attributes[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSecretServiceTokens(t *testing.T) {
+	service := newMockSecretService(t)
+
+	if !SecretServiceAvailable() {
+		t.Fatal("the mock Secret Service isn't available")
+	}
+
+	err := StoreSecretToken("account", "lemmy.example", "LemmeRead token for lemmy.example", "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = StoreSecretToken("account", "lemmy.example", "LemmeRead token for lemmy.example", "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := service.itemCount(); count != 1 {
+		t.Errorf("%d items stored for one account, expected 1", count)
+	}
+
+	token, err := LookupSecretToken("account")
+	if err != nil || token != "new" {
+		t.Errorf("got token %q (%v), expected %q", token, err, "new")
+	}
+	token, err = LookupSecretToken("other")
+	if err != nil || token != "" {
+		t.Errorf("got token %q (%v) for an unknown account", token, err)
+	}
+
+	err = ClearSecretToken("account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err = LookupSecretToken("account")
+	if err != nil || token != "" {
+		t.Errorf("got token %q (%v) after clearing it", token, err)
+	}
+	if count := service.itemCount(); count != 0 {
+		t.Errorf("%d items left after clearing the token", count)
+	}
+}
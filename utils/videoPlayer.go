@@ -0,0 +1,188 @@
+package utils
+
+// #cgo pkg-config: gstreamer-1.0 gtk+-3.0
+// #include <stdint.h>
+// #include <stdlib.h>
+// #include <gst/gst.h>
+// #include <gtk/gtk.h>
+//
+// static gboolean init_gstreamer(gchar **message) {
+// 	GError *error = NULL;
+// 	if (!gst_init_check(NULL, NULL, &error)) {
+// 		*message = g_strdup(error->message);
+// 		g_error_free(error);
+// 		return FALSE;
+// 	}
+// 	return TRUE;
+// }
+//
+// static GstElement *new_playbin(const gchar *uri, GtkWidget **widget) {
+// 	GstElement *playbin = gst_element_factory_make("playbin", NULL);
+// 	GstElement *sink = gst_element_factory_make("gtksink", NULL);
+// 	if (playbin == NULL || sink == NULL) {
+// 		if (playbin != NULL) {
+// 			gst_object_unref(gst_object_ref_sink(playbin));
+// 		}
+// 		if (sink != NULL) {
+// 			gst_object_unref(gst_object_ref_sink(sink));
+// 		}
+// 		return NULL;
+// 	}
+//
+// 	g_object_get(sink, "widget", widget, NULL);
+// 	g_object_set(playbin, "uri", uri, "video-sink", sink, NULL);
+// 	return gst_object_ref_sink(playbin);
+// }
+//
+// static gboolean set_state(GstElement *playbin, GstState state) {
+// 	return gst_element_set_state(playbin, state) != GST_STATE_CHANGE_FAILURE;
+// }
+//
+// static void set_mute(GstElement *playbin, gboolean mute) {
+// 	g_object_set(playbin, "mute", mute, NULL);
+// }
+//
+// static void rewind_playbin(GstElement *playbin) {
+// 	gst_element_seek_simple(playbin, GST_FORMAT_TIME, GST_SEEK_FLAG_FLUSH, 0);
+// }
+//
+// extern void videoBusMessage(uintptr_t player, int kind, gchar *message);
+//
+// // on_bus_message tells the player 1 if the video ended and 2, with the
+// // message, if it failed.
+// static gboolean on_bus_message(GstBus *bus, GstMessage *message, gpointer player) {
+// 	if (GST_MESSAGE_TYPE(message) == GST_MESSAGE_EOS) {
+// 		videoBusMessage((uintptr_t)player, 1, NULL);
+// 	} else if (GST_MESSAGE_TYPE(message) == GST_MESSAGE_ERROR) {
+// 		GError *error = NULL;
+// 		gst_message_parse_error(message, &error, NULL);
+// 		videoBusMessage((uintptr_t)player, 2, error->message);
+// 		g_error_free(error);
+// 	}
+// 	return G_SOURCE_CONTINUE;
+// }
+//
+// static guint watch_bus(GstElement *playbin, uintptr_t player) {
+// 	GstBus *bus = gst_element_get_bus(playbin);
+// 	guint watch = gst_bus_add_watch(bus, on_bus_message, (gpointer)player);
+// 	gst_object_unref(bus);
+// 	return watch;
+// }
+import "C"
+
+import (
+	"errors"
+	"runtime/cgo"
+	"sync"
+	"unsafe"
+
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+var (
+	gstreamerInit  sync.Once
+	gstreamerError error
+)
+
+// VideoPlayer streams a video with GStreamer into Widget. It has to be used
+// from the main thread, like any other widget.
+type VideoPlayer struct {
+	Widget *gtk.Widget
+	// Ended and Failed are called from the main loop when the video gets to
+	// its end or can't go on.
+	Ended  func()
+	Failed func(error)
+
+	playbin *C.GstElement
+	handle  cgo.Handle
+	watch   C.guint
+	ended   bool
+}
+
+// NewVideoPlayer prepares uri to be played. It fails when GStreamer or its GTK
+// sink (from gst-plugins-good) aren't available.
+func NewVideoPlayer(uri string) (*VideoPlayer, error) {
+	gstreamerInit.Do(func() {
+		var message *C.gchar
+		if C.init_gstreamer(&message) == 0 {
+			gstreamerError = takeGString(message)
+		}
+	})
+	if gstreamerError != nil {
+		return nil, gstreamerError
+	}
+
+	cUri := C.CString(uri)
+	defer C.free(unsafe.Pointer(cUri))
+
+	var widget *C.GtkWidget
+	playbin := C.new_playbin((*C.gchar)(cUri), &widget)
+	if playbin == nil {
+		return nil, errors.New("GStreamer's playbin or gtksink elements are missing")
+	}
+
+	vp := &VideoPlayer{playbin: playbin}
+	vp.Widget = &gtk.Widget{InitiallyUnowned: glib.InitiallyUnowned{Object: glib.AssumeOwnership(unsafe.Pointer(widget))}}
+	vp.handle = cgo.NewHandle(vp)
+	vp.watch = C.watch_bus(playbin, C.uintptr_t(vp.handle))
+	return vp, nil
+}
+
+// Play resumes the video, starting it over if it had ended.
+func (vp *VideoPlayer) Play() error {
+	if vp.ended {
+		C.rewind_playbin(vp.playbin)
+		vp.ended = false
+	}
+	if C.set_state(vp.playbin, C.GST_STATE_PLAYING) == 0 {
+		return errors.New("The video couldn't be played")
+	}
+	return nil
+}
+
+func (vp *VideoPlayer) Pause() {
+	C.set_state(vp.playbin, C.GST_STATE_PAUSED)
+}
+
+func (vp *VideoPlayer) SetMuted(muted bool) {
+	var mute C.gboolean
+	if muted {
+		mute = 1
+	}
+	C.set_mute(vp.playbin, mute)
+}
+
+//export videoBusMessage
+func videoBusMessage(player C.uintptr_t, kind C.int, message *C.gchar) {
+	vp := cgo.Handle(player).Value().(*VideoPlayer)
+	switch kind {
+	case 1:
+		vp.ended = true
+		if vp.Ended != nil {
+			vp.Ended()
+		}
+	case 2:
+		if vp.Failed != nil {
+			vp.Failed(errors.New(C.GoString((*C.char)(message))))
+		}
+	}
+}
+
+// Destroy stops the video and releases the pipeline. The player can't be used
+// afterwards.
+func (vp *VideoPlayer) Destroy() {
+	if vp.playbin == nil {
+		return
+	}
+	C.g_source_remove(vp.watch)
+	vp.handle.Delete()
+	C.set_state(vp.playbin, C.GST_STATE_NULL)
+	C.gst_object_unref(C.gpointer(unsafe.Pointer(vp.playbin)))
+	vp.playbin = nil
+}
+
+func takeGString(message *C.gchar) error {
+	defer C.g_free(C.gpointer(unsafe.Pointer(message)))
+	return errors.New(C.GoString((*C.char)(message)))
+}
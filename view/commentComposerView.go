@@ -0,0 +1,114 @@
+package view
+
+import (
+	"log"
+	"strings"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+type CommentComposerView struct {
+	ComposerBox *gtk.Box
+	Submitted   func(string)
+	Cancelled   func()
+
+	text   *gtk.TextView
+	cancel *gtk.Button
+	send   *gtk.Button
+}
+
+func NewCommentComposerView(initialText string, cancellable bool) (ccv *CommentComposerView, err error) {
+	ccv = &CommentComposerView{}
+	_, err = ccv.buildAndSetReferences()
+	if err != nil {
+		return
+	}
+
+	buffer, err := ccv.text.GetBuffer()
+	if err != nil {
+		return
+	}
+	buffer.SetText(initialText)
+
+	if cancellable {
+		ccv.cancel.Show()
+	}
+
+	return
+}
+
+func (ccv *CommentComposerView) SetBusy(busy bool) {
+	ccv.text.SetSensitive(!busy)
+	ccv.send.SetSensitive(!busy)
+	ccv.cancel.SetSensitive(!busy)
+}
+
+func (ccv *CommentComposerView) Clear() {
+	buffer, err := ccv.text.GetBuffer()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	buffer.SetText("")
+}
+
+func (ccv *CommentComposerView) Destroy() {
+	ccv.ComposerBox.Destroy()
+	ccv.Submitted = nil
+	ccv.Cancelled = nil
+}
+
+func (ccv *CommentComposerView) buildAndSetReferences() (builder *gtk.Builder, err error) {
+	builder, err = gtk.BuilderNewFromString(string(data.CommentComposerUI))
+	if err != nil {
+		return
+	}
+
+	ccv.text, err = utils.GetUIObject[gtk.TextView](builder, "text")
+	if err != nil {
+		return
+	}
+
+	ccv.cancel, err = utils.GetUIObject[gtk.Button](builder, "cancel")
+	if err != nil {
+		return
+	}
+	ccv.cancel.Connect("clicked", func() {
+		if ccv.Cancelled != nil {
+			ccv.Cancelled()
+		}
+	})
+
+	ccv.send, err = utils.GetUIObject[gtk.Button](builder, "send")
+	if err != nil {
+		return
+	}
+	ccv.send.Connect("clicked", func() {
+		buffer, err := ccv.text.GetBuffer()
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		text, err := buffer.GetText(buffer.GetStartIter(), buffer.GetEndIter(), false)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		text = strings.TrimSpace(text)
+		if text != "" && ccv.Submitted != nil {
+			ccv.Submitted(text)
+		}
+	})
+
+	ccv.ComposerBox, err = utils.GetUIObject[gtk.Box](builder, "composer")
+	if err != nil {
+		return
+	}
+	ccv.ComposerBox.Unparent()
+
+	return
+}
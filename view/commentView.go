@@ -1,6 +1,8 @@
 package view
 
 import (
+	"context"
+	"log"
 	"time"
 
 	"github.com/gotk3/gotk3/gdk"
@@ -11,21 +13,40 @@ import (
 )
 
 type CommentView struct {
-	CommentBox   *gtk.Box
-	VotesChanged func(int64, int64)
+	CommentBox     *gtk.Box
+	VotesChanged   func(int64, int64)
+	SaveClicked    func(int64, bool)
+	ReplySubmitted func(int64, string)
+	EditSubmitted  func(int64, string)
+	DeleteClicked  func(int64)
+	UserClicked    func(int64)
 
 	commentID        int64
+	creatorID        int64
+	content          string
+	editable         bool
+	baseScore        int64
+	updatingVotes    bool
+	saved            bool
+	composer         *CommentComposerView
 	username         *gtk.Label
 	timestamp        *gtk.Label
-	commentText      *gtk.Label
+	commentText      *gtk.Box
+	commentContent   *MarkdownView
 	votes            *gtk.SpinButton
 	userImage        *gtk.Image
 	foldButton       *gtk.Button
 	unfoldButton     *gtk.Button
+	saveButton       *gtk.Button
+	replyButton      *gtk.Button
+	editButton       *gtk.Button
+	deleteButton     *gtk.Button
+	composerBox      *gtk.Box
 	childCommentsBox *gtk.Box
 }
 
-func NewCommentView(comment model.CommentModel) (cv CommentView, err error) {
+func NewCommentView(comment model.CommentModel, editable bool) (cv *CommentView, err error) {
+	cv = &CommentView{editable: editable}
 	_, err = cv.buildAndSetReferences()
 	if err != nil {
 		return
@@ -36,8 +57,49 @@ func NewCommentView(comment model.CommentModel) (cv CommentView, err error) {
 	return
 }
 
-func (cv *CommentView) AddChildComment(commentView CommentView) {
+func (cv *CommentView) AddChildComment(commentView *CommentView) {
 	cv.childCommentsBox.PackStart(commentView.CommentBox, true, false, 0)
+	cv.childCommentsBox.ReorderChild(commentView.CommentBox, 0)
+}
+
+func (cv *CommentView) UpdateComment(comment model.CommentModel) {
+	cv.setContent(comment)
+	cv.UpdateVotes(comment)
+}
+
+func (cv *CommentView) SetReadOnly() {
+	cv.votes.SetSensitive(false)
+	cv.saveButton.Hide()
+	cv.replyButton.Hide()
+	cv.editButton.Hide()
+	cv.deleteButton.Hide()
+}
+
+func (cv *CommentView) SetComposerBusy(busy bool) {
+	if cv.composer != nil {
+		cv.composer.SetBusy(busy)
+	}
+}
+
+func (cv *CommentView) CloseComposer() {
+	if cv.composer == nil {
+		return
+	}
+
+	cv.composer.Destroy()
+	cv.composer = nil
+	cv.commentText.Show()
+}
+
+func (cv *CommentView) UpdateVotes(comment model.CommentModel) {
+	cv.updatingVotes = true
+	cv.baseScore = setVotesRange(cv.votes, comment.Counts.Score, comment.MyVote.ValueOrZero())
+	cv.updatingVotes = false
+}
+
+func (cv *CommentView) UpdateSaved(comment model.CommentModel) {
+	cv.saved = comment.Saved
+	cv.saveButton.SetLabel(saveLabel(comment.Saved))
 }
 
 func (cv *CommentView) buildAndSetReferences() (commentBox *gtk.Box, err error) {
@@ -52,15 +114,26 @@ func (cv *CommentView) buildAndSetReferences() (commentBox *gtk.Box, err error)
 		return
 	}
 
+	usernameEvents, err := utils.GetUIObject[gtk.EventBox](builder, "usernameEvents")
+	if err != nil {
+		return
+	}
+	usernameEvents.Connect("button-press-event", func() {
+		if cv.UserClicked != nil {
+			cv.UserClicked(cv.creatorID)
+		}
+	})
+
 	cv.timestamp, err = utils.GetUIObject[gtk.Label](builder, "timestamp")
 	if err != nil {
 		return
 	}
 
-	cv.commentText, err = utils.GetUIObject[gtk.Label](builder, "commentText")
+	cv.commentText, err = utils.GetUIObject[gtk.Box](builder, "commentText")
 	if err != nil {
 		return
 	}
+	cv.commentContent = NewMarkdownView(cv.commentText)
 
 	cv.votes, err = utils.GetUIObject[gtk.SpinButton](builder, "votes")
 	if err != nil {
@@ -68,8 +141,8 @@ func (cv *CommentView) buildAndSetReferences() (commentBox *gtk.Box, err error)
 	}
 	cv.votes.SetIncrements(1, 1)
 	cv.votes.Connect("value-changed", func() {
-		if cv.VotesChanged != nil {
-			cv.VotesChanged(cv.commentID, int64(cv.votes.GetValue()))
+		if !cv.updatingVotes && cv.VotesChanged != nil {
+			cv.VotesChanged(cv.commentID, int64(cv.votes.GetValue())-cv.baseScore)
 		}
 	})
 
@@ -102,6 +175,56 @@ func (cv *CommentView) buildAndSetReferences() (commentBox *gtk.Box, err error)
 		cv.childCommentsBox.Show()
 	})
 
+	cv.saveButton, err = utils.GetUIObject[gtk.Button](builder, "save")
+	if err != nil {
+		return
+	}
+	cv.saveButton.Connect("clicked", func() {
+		if cv.SaveClicked != nil {
+			cv.SaveClicked(cv.commentID, !cv.saved)
+		}
+	})
+
+	cv.replyButton, err = utils.GetUIObject[gtk.Button](builder, "reply")
+	if err != nil {
+		return
+	}
+	cv.replyButton.Connect("clicked", func() {
+		cv.openComposer("", func(text string) {
+			if cv.ReplySubmitted != nil {
+				cv.ReplySubmitted(cv.commentID, text)
+			}
+		})
+	})
+
+	cv.editButton, err = utils.GetUIObject[gtk.Button](builder, "edit")
+	if err != nil {
+		return
+	}
+	cv.editButton.Connect("clicked", func() {
+		cv.openComposer(cv.content, func(text string) {
+			if cv.EditSubmitted != nil {
+				cv.EditSubmitted(cv.commentID, text)
+			}
+		})
+		cv.commentText.Hide()
+	})
+
+	cv.deleteButton, err = utils.GetUIObject[gtk.Button](builder, "delete")
+	if err != nil {
+		return
+	}
+	cv.deleteButton.Connect("clicked", func() {
+		if cv.DeleteClicked != nil && utils.Confirm(&cv.CommentBox.Widget, "Delete this comment?") {
+			cv.DeleteClicked(cv.commentID)
+		}
+	})
+
+	cv.composerBox, err = utils.GetUIObject[gtk.Box](builder, "composerParent")
+	if err != nil {
+		return
+	}
+
 	cv.childCommentsBox, err = utils.GetUIObject[gtk.Box](builder, "children")
 
 	cv.CommentBox, err = utils.GetUIObject[gtk.Box](builder, "commentBox")
@@ -115,13 +238,13 @@ func (cv *CommentView) buildAndSetReferences() (commentBox *gtk.Box, err error)
 
 func (cv *CommentView) fillCommentData(comment model.CommentModel) {
 	cv.commentID = comment.Comment.ID
+	cv.creatorID = comment.Creator.ID
 	cv.username.SetText(comment.Creator.DisplayName.ValueOr(comment.Creator.Name))
 	cv.timestamp.SetText(utils.GetNiceDuration(time.Since(comment.Comment.Published)))
 
-	cv.commentText.SetMarkup(utils.MarkdownToLabelMarkup(comment.Comment.Content))
-
-	cv.votes.SetRange(float64(comment.Counts.Score)-1, float64(comment.Counts.Score)+1)
-	cv.votes.SetValue(float64(comment.Counts.Score))
+	cv.setContent(comment)
+	cv.UpdateVotes(comment)
+	cv.UpdateSaved(comment)
 
 	if comment.Creator.Avatar.IsValid() {
 		var taskSequence *utils.TaskSequence[*gdk.Pixbuf]
@@ -130,10 +253,40 @@ func (cv *CommentView) fillCommentData(comment model.CommentModel) {
 		})
 
 		taskSequence.Add(func() (*gdk.Pixbuf, error) {
-			return utils.LoadPixmapFromUrl(comment.Creator.Avatar.ValueOrZero())
+			return utils.LoadPixmapFromUrl(context.Background(), comment.Creator.Avatar.ValueOrZero())
 		}, func(pixbuf *gdk.Pixbuf, err error) bool {
 			utils.SetDirectImage(cv.userImage, pixbuf, [2]int{communityIconSize, communityIconSize}, err)
 			return true
 		})
 	}
 }
+
+func (cv *CommentView) setContent(comment model.CommentModel) {
+	cv.content = comment.Comment.Content
+	removed := comment.Comment.Deleted || comment.Comment.Removed
+
+	if removed {
+		cv.commentContent.SetMarkup("<i>deleted</i>")
+	} else {
+		cv.commentContent.SetMarkdown(comment.Comment.Content)
+	}
+
+	cv.replyButton.SetVisible(!removed)
+	cv.editButton.SetVisible(cv.editable && !removed)
+	cv.deleteButton.SetVisible(cv.editable && !removed)
+}
+
+func (cv *CommentView) openComposer(initialText string, submitted func(string)) {
+	cv.CloseComposer()
+
+	var err error
+	cv.composer, err = NewCommentComposerView(initialText, true)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	cv.composer.Submitted = submitted
+	cv.composer.Cancelled = cv.CloseComposer
+	cv.composerBox.PackStart(cv.composer.ComposerBox, true, true, 0)
+}
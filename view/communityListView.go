@@ -0,0 +1,127 @@
+package view
+
+import (
+	"log"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+type CommunityListView struct {
+	OrderChanged     func(int)
+	FilterChanged    func(int)
+	SubscribeClicked func(int64, bool)
+	ShowPostsClicked func(int64)
+	ReadOnly         bool
+
+	communityViews map[int64]*CommunityView
+	listBox        *gtk.Box
+	order          *gtk.ComboBoxText
+	filter         *gtk.ComboBoxText
+	communitiesBox *gtk.Box
+}
+
+func (clv *CommunityListView) SetupCommunityListView(box *gtk.Box, order model.CommunitiesOrder, filter model.PostsFilter) (err error) {
+	_, err = clv.buildAndSetReferences()
+	if err != nil {
+		return
+	}
+
+	clv.communityViews = make(map[int64]*CommunityView)
+	clv.order.SetActive(int(order))
+	clv.filter.SetActive(int(filter))
+	clv.order.Connect("changed", func() {
+		if clv.OrderChanged != nil {
+			clv.OrderChanged(clv.order.GetActive())
+		}
+	})
+	clv.filter.Connect("changed", func() {
+		if clv.FilterChanged != nil {
+			clv.FilterChanged(clv.filter.GetActive())
+		}
+	})
+
+	box.PackStart(clv.listBox, true, true, 0)
+
+	return
+}
+
+func (clv *CommunityListView) CleanView() {
+	clv.communityViews = make(map[int64]*CommunityView)
+	removeAllChildren(&clv.communitiesBox.Container)
+}
+
+func (clv *CommunityListView) FillCommunities(communities []model.CommunityModel) {
+	for _, community := range communities {
+		if _, ok := clv.communityViews[community.Community.ID]; ok {
+			continue
+		}
+
+		communityView, err := NewCommunityView(community, true)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if clv.ReadOnly {
+			communityView.SetReadOnly()
+		}
+		communityView.SubscribeClicked = func(communityID int64, follow bool) {
+			if clv.SubscribeClicked != nil {
+				clv.SubscribeClicked(communityID, follow)
+			}
+		}
+		communityView.ShowPostsClicked = func(communityID int64) {
+			if clv.ShowPostsClicked != nil {
+				clv.ShowPostsClicked(communityID)
+			}
+		}
+
+		clv.communityViews[community.Community.ID] = communityView
+		clv.communitiesBox.PackStart(communityView.CommunityBox, false, false, 0)
+	}
+}
+
+func (clv *CommunityListView) UpdateCommunity(community model.CommunityModel) {
+	if communityView, ok := clv.communityViews[community.Community.ID]; ok {
+		communityView.UpdateCommunity(community)
+	}
+}
+
+func (clv *CommunityListView) SetCommunityBusy(communityID int64, busy bool) {
+	if communityView, ok := clv.communityViews[communityID]; ok {
+		communityView.SetBusy(busy)
+	}
+}
+
+func (clv *CommunityListView) buildAndSetReferences() (builder *gtk.Builder, err error) {
+	builder, err = gtk.BuilderNewFromString(string(data.CommunityListUI))
+	if err != nil {
+		return
+	}
+
+	clv.order, err = utils.GetUIObject[gtk.ComboBoxText](builder, "order")
+	if err != nil {
+		return
+	}
+
+	clv.filter, err = utils.GetUIObject[gtk.ComboBoxText](builder, "filter")
+	if err != nil {
+		return
+	}
+
+	clv.communitiesBox, err = utils.GetUIObject[gtk.Box](builder, "communities")
+	if err != nil {
+		return
+	}
+
+	clv.listBox, err = utils.GetUIObject[gtk.Box](builder, "communityList")
+	if err != nil {
+		return
+	}
+	clv.listBox.Unparent()
+
+	return
+}
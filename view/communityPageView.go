@@ -0,0 +1,64 @@
+package view
+
+import (
+	"log"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/model"
+)
+
+type CommunityPageView struct {
+	PostListView
+	SubscribeClicked func(int64, bool)
+
+	communityView *CommunityView
+	headerBox     *gtk.Box
+}
+
+func (cpv *CommunityPageView) SetupCommunityPageView(box *gtk.Box) (err error) {
+	cpv.headerBox, err = gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 0)
+	if err != nil {
+		return
+	}
+	cpv.headerBox.Show()
+	box.PackStart(cpv.headerBox, false, false, 0)
+
+	postsBox, err := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 10)
+	if err != nil {
+		return
+	}
+	postsBox.Show()
+	box.PackStart(postsBox, false, false, 0)
+
+	return cpv.SetupPostListView(postsBox)
+}
+
+func (cpv *CommunityPageView) SetCommunity(community model.CommunityModel) {
+	if cpv.communityView != nil {
+		cpv.communityView.UpdateCommunity(community)
+		return
+	}
+
+	var err error
+	cpv.communityView, err = NewCommunityView(community, false)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if cpv.ReadOnly {
+		cpv.communityView.SetReadOnly()
+	}
+	cpv.communityView.SubscribeClicked = func(communityID int64, follow bool) {
+		if cpv.SubscribeClicked != nil {
+			cpv.SubscribeClicked(communityID, follow)
+		}
+	}
+	cpv.headerBox.PackStart(cpv.communityView.CommunityBox, false, false, 0)
+}
+
+func (cpv *CommunityPageView) SetBusy(busy bool) {
+	if cpv.communityView != nil {
+		cpv.communityView.SetBusy(busy)
+	}
+}
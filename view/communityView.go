@@ -0,0 +1,165 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+const communityCardIconSize = 48
+
+type CommunityView struct {
+	CommunityBox     *gtk.Box
+	SubscribeClicked func(int64, bool)
+	ShowPostsClicked func(int64)
+
+	communityID int64
+	subscribed  bool
+	banner      *gtk.Image
+	icon        *gtk.Image
+	title       *gtk.Label
+	name        *gtk.Label
+	subscribers *gtk.Label
+	description *gtk.Label
+	markdown    *MarkdownLabel
+	showPosts   *gtk.Button
+	subscribe   *gtk.Button
+}
+
+func NewCommunityView(community model.CommunityModel, briefDesc bool) (cv *CommunityView, err error) {
+	cv = &CommunityView{}
+	_, err = cv.buildAndSetReferences()
+	if err != nil {
+		return
+	}
+
+	cv.fillCommunityData(community, briefDesc)
+
+	return
+}
+
+func (cv *CommunityView) UpdateCommunity(community model.CommunityModel) {
+	cv.subscribed = community.IsSubscribed()
+	cv.subscribers.SetText(fmt.Sprintf("%d subscribers", community.Counts.Subscribers))
+	cv.subscribe.SetSensitive(true)
+
+	if community.IsSubscriptionPending() {
+		cv.subscribe.SetLabel("Pending")
+	} else if cv.subscribed {
+		cv.subscribe.SetLabel("Unsubscribe")
+	} else {
+		cv.subscribe.SetLabel("Subscribe")
+	}
+}
+
+func (cv *CommunityView) SetReadOnly() {
+	cv.subscribe.Hide()
+}
+
+func (cv *CommunityView) SetBusy(busy bool) {
+	cv.subscribe.SetSensitive(!busy)
+}
+
+func (cv *CommunityView) buildAndSetReferences() (builder *gtk.Builder, err error) {
+	builder, err = gtk.BuilderNewFromString(string(data.CommunityUI))
+	if err != nil {
+		return
+	}
+
+	utils.SetWidgetProperty(builder, "card", func(card *gtk.Box) {
+		utils.ApplyStyle(&card.Widget)
+	})
+
+	cv.banner, err = utils.GetUIObject[gtk.Image](builder, "banner")
+	if err != nil {
+		return
+	}
+
+	cv.icon, err = utils.GetUIObject[gtk.Image](builder, "icon")
+	if err != nil {
+		return
+	}
+
+	cv.title, err = utils.GetUIObject[gtk.Label](builder, "title")
+	if err != nil {
+		return
+	}
+	utils.ApplyStyle(&cv.title.Widget)
+
+	cv.name, err = utils.GetUIObject[gtk.Label](builder, "name")
+	if err != nil {
+		return
+	}
+
+	cv.subscribers, err = utils.GetUIObject[gtk.Label](builder, "subscribers")
+	if err != nil {
+		return
+	}
+
+	cv.description, err = utils.GetUIObject[gtk.Label](builder, "description")
+	if err != nil {
+		return
+	}
+	cv.markdown = NewMarkdownLabel(cv.description)
+
+	cv.showPosts, err = utils.GetUIObject[gtk.Button](builder, "showPosts")
+	if err != nil {
+		return
+	}
+	cv.showPosts.Connect("clicked", func() {
+		if cv.ShowPostsClicked != nil {
+			cv.ShowPostsClicked(cv.communityID)
+		}
+	})
+
+	cv.subscribe, err = utils.GetUIObject[gtk.Button](builder, "subscribe")
+	if err != nil {
+		return
+	}
+	cv.subscribe.Connect("clicked", func() {
+		if cv.SubscribeClicked != nil {
+			cv.SubscribeClicked(cv.communityID, !cv.subscribed)
+		}
+	})
+
+	cv.CommunityBox, err = utils.GetUIObject[gtk.Box](builder, "community")
+	if err != nil {
+		return
+	}
+	cv.CommunityBox.Unparent()
+
+	return
+}
+
+func (cv *CommunityView) fillCommunityData(community model.CommunityModel, briefDesc bool) {
+	cv.communityID = community.Community.ID
+	cv.title.SetText(community.Community.Title)
+	cv.name.SetText("!" + community.Community.Name)
+
+	if community.Community.Description.IsValid() {
+		description := community.Community.Description.ValueOrZero()
+		if briefDesc && len(description) > MAX_BRIEF_DESC_LEN {
+			description = description[:MAX_BRIEF_DESC_LEN] + "..."
+		}
+		cv.markdown.SetMarkdown(description)
+	} else {
+		cv.description.Hide()
+	}
+
+	if community.Icon != nil {
+		utils.SetDirectImage(cv.icon, community.Icon, [2]int{communityCardIconSize, communityCardIconSize}, nil)
+	}
+
+	if !briefDesc {
+		cv.showPosts.Hide()
+		if community.Banner != nil {
+			utils.SetDirectImage(cv.banner, community.Banner, [2]int{maxPostImageSize, maxPostImageSize}, nil)
+			cv.banner.Show()
+		}
+	}
+
+	cv.UpdateCommunity(community)
+}
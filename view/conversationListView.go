@@ -0,0 +1,93 @@
+package view
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+const maxConversationSnippetLen = 80
+
+type ConversationListView struct {
+	ConversationClicked func(int64)
+
+	counterpartIDs []int64
+	conversations  *gtk.Box
+	empty          *gtk.Label
+	list           *gtk.ListBox
+}
+
+func (clv *ConversationListView) SetupConversationListView(box *gtk.Box) (err error) {
+	_, err = clv.buildAndSetReferences()
+	if err != nil {
+		return
+	}
+
+	box.PackStart(clv.conversations, true, true, 0)
+
+	return
+}
+
+func (clv *ConversationListView) FillConversations(conversations []*model.ConversationModel, myUserID int64) {
+	clv.counterpartIDs = make([]int64, 0, len(conversations))
+	removeAllChildren(&clv.list.Container)
+
+	for _, conversation := range conversations {
+		name := conversation.Counterpart.DisplayName.ValueOr(conversation.Counterpart.Name)
+		markup := fmt.Sprintf("<b>%s</b>  @%s", html.EscapeString(name), html.EscapeString(conversation.Counterpart.Name))
+
+		if unread := len(conversation.UnreadMessages(myUserID)); unread > 0 {
+			markup += fmt.Sprintf("  <b>(%d new)</b>", unread)
+		}
+		if last, ok := conversation.LastMessage(); ok {
+			snippet := strings.ReplaceAll(last.PrivateMessage.Content, "\n", " ")
+			if len(snippet) > maxConversationSnippetLen {
+				snippet = snippet[:maxConversationSnippetLen] + "..."
+			}
+			markup += fmt.Sprintf("\n<i>%s</i>", html.EscapeString(snippet))
+		}
+
+		addResultRow(clv.list, markup)
+		clv.counterpartIDs = append(clv.counterpartIDs, conversation.Counterpart.ID)
+	}
+}
+
+func (clv *ConversationListView) ShowEmptyIfNoConversations() {
+	clv.empty.SetVisible(len(clv.counterpartIDs) == 0)
+}
+
+func (clv *ConversationListView) buildAndSetReferences() (builder *gtk.Builder, err error) {
+	builder, err = gtk.BuilderNewFromString(string(data.ConversationsUI))
+	if err != nil {
+		return
+	}
+
+	clv.empty, err = utils.GetUIObject[gtk.Label](builder, "empty")
+	if err != nil {
+		return
+	}
+
+	clv.list, err = utils.GetUIObject[gtk.ListBox](builder, "list")
+	if err != nil {
+		return
+	}
+	clv.list.Connect("row-activated", func(list *gtk.ListBox, row *gtk.ListBoxRow) {
+		index := row.GetIndex()
+		if index >= 0 && index < len(clv.counterpartIDs) && clv.ConversationClicked != nil {
+			clv.ConversationClicked(clv.counterpartIDs[index])
+		}
+	})
+
+	clv.conversations, err = utils.GetUIObject[gtk.Box](builder, "conversations")
+	if err != nil {
+		return
+	}
+	clv.conversations.Unparent()
+
+	return
+}
@@ -0,0 +1,125 @@
+package view
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+type ConversationView struct {
+	MessageSent    func(int64, string)
+	MessageEdited  func(int64, string)
+	MessageDeleted func(int64)
+
+	CounterpartID int64
+	messageViews  map[int64]*MessageView
+	composer      *CommentComposerView
+	conversation  *gtk.Box
+	title         *gtk.Label
+	messagesBox   *gtk.Box
+	composerBox   *gtk.Box
+}
+
+func (cv *ConversationView) SetupConversationView(box *gtk.Box, conversation model.ConversationModel, myUserID int64) (err error) {
+	_, err = cv.buildAndSetReferences()
+	if err != nil {
+		return
+	}
+
+	cv.CounterpartID = conversation.Counterpart.ID
+	cv.messageViews = make(map[int64]*MessageView)
+	cv.title.SetText(fmt.Sprintf("Conversation with %s", conversation.Counterpart.DisplayName.ValueOr(conversation.Counterpart.Name)))
+
+	cv.composer, err = NewCommentComposerView("", false)
+	if err != nil {
+		return
+	}
+	cv.composer.Submitted = func(text string) {
+		if cv.MessageSent != nil {
+			cv.MessageSent(cv.CounterpartID, text)
+		}
+	}
+	cv.composerBox.PackStart(cv.composer.ComposerBox, true, true, 0)
+
+	cv.UpdateConversation(conversation, myUserID)
+	box.PackStart(cv.conversation, true, true, 0)
+
+	return
+}
+
+func (cv *ConversationView) UpdateConversation(conversation model.ConversationModel, myUserID int64) {
+	for idx, message := range conversation.Messages {
+		if messageView, ok := cv.messageViews[message.PrivateMessage.ID]; ok {
+			messageView.UpdateMessage(message)
+			continue
+		}
+
+		messageView, err := NewMessageView(message, message.Creator.ID == myUserID)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		messageView.EditSubmitted = func(messageID int64, text string) {
+			if cv.MessageEdited != nil {
+				cv.MessageEdited(messageID, text)
+			}
+		}
+		messageView.DeleteClicked = func(messageID int64) {
+			if cv.MessageDeleted != nil {
+				cv.MessageDeleted(messageID)
+			}
+		}
+
+		cv.messageViews[message.PrivateMessage.ID] = messageView
+		cv.messagesBox.PackStart(messageView.MessageBox, false, false, 0)
+		cv.messagesBox.ReorderChild(messageView.MessageBox, idx)
+	}
+}
+
+func (cv *ConversationView) SetComposerBusy(messageID int64, busy bool) {
+	if messageView, ok := cv.messageViews[messageID]; ok {
+		messageView.SetComposerBusy(busy)
+	} else if messageID == 0 {
+		cv.composer.SetBusy(busy)
+	}
+}
+
+func (cv *ConversationView) ClearComposer() {
+	cv.composer.Clear()
+	cv.composer.SetBusy(false)
+}
+
+func (cv *ConversationView) buildAndSetReferences() (builder *gtk.Builder, err error) {
+	builder, err = gtk.BuilderNewFromString(string(data.ConversationUI))
+	if err != nil {
+		return
+	}
+
+	cv.title, err = utils.GetUIObject[gtk.Label](builder, "title")
+	if err != nil {
+		return
+	}
+
+	cv.messagesBox, err = utils.GetUIObject[gtk.Box](builder, "messages")
+	if err != nil {
+		return
+	}
+
+	cv.composerBox, err = utils.GetUIObject[gtk.Box](builder, "composerParent")
+	if err != nil {
+		return
+	}
+
+	cv.conversation, err = utils.GetUIObject[gtk.Box](builder, "conversation")
+	if err != nil {
+		return
+	}
+	cv.conversation.Unparent()
+
+	return
+}
@@ -0,0 +1,224 @@
+package view
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+type CreatePostView struct {
+	Window                 *gtk.Dialog
+	CommunitySearchChanged func(string)
+	UploadClicked          func(string)
+	Submitted              func(int64, string, string, string, bool)
+	Cancelled              func()
+
+	communityIDs    []int64
+	communitySearch *gtk.SearchEntry
+	communityList   *gtk.ListBox
+	title           *gtk.Entry
+	url             *gtk.Entry
+	imageChooser    *gtk.FileChooserButton
+	upload          *gtk.Button
+	body            *gtk.TextView
+	nsfw            *gtk.CheckButton
+	status          *gtk.Label
+	cancel          *gtk.Button
+	submit          *gtk.Button
+}
+
+func (cpv *CreatePostView) SetupCreatePostView(parent *gtk.ApplicationWindow) (err error) {
+	_, err = cpv.buildAndSetReferences()
+	if err != nil {
+		return
+	}
+
+	cpv.Window.SetTransientFor(parent)
+	cpv.Window.Show()
+
+	return nil
+}
+
+func (cpv *CreatePostView) SetCommunities(communities []model.CommunityModel) {
+	cpv.communityIDs = make([]int64, 0, len(communities))
+	cpv.communityList.GetChildren().Foreach(func(child interface{}) {
+		widget, ok := child.(gtk.IWidget)
+		if ok {
+			cpv.communityList.Remove(widget)
+		}
+	})
+
+	for _, community := range communities {
+		label, err := gtk.LabelNew(fmt.Sprintf("%s (%s)", community.Community.Title, community.Community.Name))
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		label.SetXAlign(0)
+		label.Show()
+		cpv.communityList.Add(label)
+		cpv.communityIDs = append(cpv.communityIDs, community.Community.ID)
+	}
+}
+
+func (cpv *CreatePostView) SetImageUrl(url string) {
+	cpv.url.SetText(url)
+}
+
+func (cpv *CreatePostView) SetBusy(busy bool) {
+	cpv.submit.SetSensitive(!busy)
+	cpv.upload.SetSensitive(!busy)
+}
+
+func (cpv *CreatePostView) SetStatus(status string) {
+	cpv.status.SetText(status)
+	cpv.status.SetVisible(status != "")
+}
+
+func (cpv *CreatePostView) DestroyWindow() {
+	cpv.Window.Destroy()
+	cpv.CommunitySearchChanged = nil
+	cpv.UploadClicked = nil
+	cpv.Submitted = nil
+	cpv.Cancelled = nil
+}
+
+func (cpv *CreatePostView) buildAndSetReferences() (builder *gtk.Builder, err error) {
+	builder, err = gtk.BuilderNewFromString(string(data.CreatePostUI))
+	if err != nil {
+		return
+	}
+
+	cpv.Window, err = utils.GetUIObject[gtk.Dialog](builder, "createPostDialog")
+	if err != nil {
+		return
+	}
+
+	cpv.communitySearch, err = utils.GetUIObject[gtk.SearchEntry](builder, "communitySearch")
+	if err != nil {
+		return
+	}
+	cpv.communitySearch.Connect("search-changed", func() {
+		query, err := cpv.communitySearch.GetText()
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if strings.TrimSpace(query) != "" && cpv.CommunitySearchChanged != nil {
+			cpv.CommunitySearchChanged(query)
+		}
+	})
+
+	cpv.communityList, err = utils.GetUIObject[gtk.ListBox](builder, "communityList")
+	if err != nil {
+		return
+	}
+
+	cpv.title, err = utils.GetUIObject[gtk.Entry](builder, "title")
+	if err != nil {
+		return
+	}
+
+	cpv.url, err = utils.GetUIObject[gtk.Entry](builder, "url")
+	if err != nil {
+		return
+	}
+
+	cpv.imageChooser, err = utils.GetUIObject[gtk.FileChooserButton](builder, "imageChooser")
+	if err != nil {
+		return
+	}
+
+	cpv.upload, err = utils.GetUIObject[gtk.Button](builder, "upload")
+	if err != nil {
+		return
+	}
+	cpv.upload.Connect("clicked", func() {
+		filename := cpv.imageChooser.GetFilename()
+		if filename != "" && cpv.UploadClicked != nil {
+			cpv.UploadClicked(filename)
+		}
+	})
+
+	cpv.body, err = utils.GetUIObject[gtk.TextView](builder, "body")
+	if err != nil {
+		return
+	}
+
+	cpv.nsfw, err = utils.GetUIObject[gtk.CheckButton](builder, "nsfw")
+	if err != nil {
+		return
+	}
+
+	cpv.status, err = utils.GetUIObject[gtk.Label](builder, "status")
+	if err != nil {
+		return
+	}
+
+	cpv.cancel, err = utils.GetUIObject[gtk.Button](builder, "cancel")
+	if err != nil {
+		return
+	}
+	cpv.cancel.Connect("clicked", func() {
+		if cpv.Cancelled != nil {
+			cpv.Cancelled()
+		}
+	})
+
+	cpv.submit, err = utils.GetUIObject[gtk.Button](builder, "submit")
+	if err != nil {
+		return
+	}
+	cpv.submit.Connect("clicked", cpv.onSubmitClicked)
+
+	return
+}
+
+func (cpv *CreatePostView) onSubmitClicked() {
+	if cpv.Submitted == nil {
+		return
+	}
+
+	row := cpv.communityList.GetSelectedRow()
+	if row == nil || row.GetIndex() < 0 || row.GetIndex() >= len(cpv.communityIDs) {
+		cpv.SetStatus("Choose a community first.")
+		return
+	}
+	communityID := cpv.communityIDs[row.GetIndex()]
+
+	title, err := cpv.title.GetText()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	title = strings.TrimSpace(title)
+	if title == "" {
+		cpv.SetStatus("The post needs a title.")
+		return
+	}
+
+	url, err := cpv.url.GetText()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	buffer, err := cpv.body.GetBuffer()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	body, err := buffer.GetText(buffer.GetStartIter(), buffer.GetEndIter(), false)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	cpv.SetStatus("")
+	cpv.Submitted(communityID, title, strings.TrimSpace(url), strings.TrimSpace(body), cpv.nsfw.GetActive())
+}
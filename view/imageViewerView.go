@@ -0,0 +1,522 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"path"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+const (
+	minViewerZoom  = 0.05
+	maxViewerZoom  = 8
+	viewerZoomStep = 1.25
+	// Scaled copies bigger than this would take hundreds of megabytes.
+	maxViewerImageSide = 8192
+)
+
+// ViewerImage is one of the images the viewer can go through.
+type ViewerImage struct {
+	URL   string
+	Title string
+}
+
+// ImageViewerView shows images at their original resolution in a window of
+// their own, where they can be zoomed, dragged around, copied and saved.
+type ImageViewerView struct {
+	Window *gtk.Window
+
+	images     []ViewerImage
+	index      int
+	ctx        context.Context
+	stop       context.CancelFunc
+	cancel     context.CancelFunc
+	pixbuf     *gdk.Pixbuf
+	animation  *gdk.PixbufAnimation
+	zoom       float64
+	fit        bool
+	fullscreen bool
+	dragging   bool
+	dragStart  [2]float64
+	dragScroll [2]float64
+	scroll     *gtk.ScrolledWindow
+	canvas     *gtk.EventBox
+	image      *gtk.Image
+	position   *gtk.Label
+	title      *gtk.Label
+	zoomLevel  *gtk.Label
+	status     *gtk.Label
+	previous   *gtk.Button
+	next       *gtk.Button
+	copyButton *gtk.Button
+	saveButton *gtk.Button
+}
+
+// openImageViewer shows images, starting by the one at index, on top of the
+// window holding widget.
+func openImageViewer(widget *gtk.Widget, images []ViewerImage, index int) {
+	var parent gtk.IWindow
+	if toplevel, err := widget.GetToplevel(); err == nil {
+		parent, _ = toplevel.(gtk.IWindow)
+	}
+
+	_, err := NewImageViewerView(parent, images, index)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func NewImageViewerView(parent gtk.IWindow, images []ViewerImage, index int) (ivv *ImageViewerView, err error) {
+	ivv = &ImageViewerView{images: images, zoom: 1, fit: true}
+	ivv.ctx, ivv.stop = context.WithCancel(context.Background())
+	_, err = ivv.buildAndSetReferences()
+	if err != nil {
+		return
+	}
+
+	if parent != nil {
+		ivv.Window.SetTransientFor(parent)
+	}
+	ivv.setFullscreen(true)
+	ivv.Window.Show()
+	ivv.show(index)
+
+	return
+}
+
+func (ivv *ImageViewerView) buildAndSetReferences() (builder *gtk.Builder, err error) {
+	builder, err = gtk.BuilderNewFromString(string(data.ImageViewerUI))
+	if err != nil {
+		return
+	}
+
+	ivv.Window, err = utils.GetUIObject[gtk.Window](builder, "imageViewer")
+	if err != nil {
+		return
+	}
+	ivv.Window.Connect("key-press-event", ivv.onKeyPress)
+	ivv.Window.Connect("destroy", func() {
+		ivv.stop()
+	})
+
+	ivv.scroll, err = utils.GetUIObject[gtk.ScrolledWindow](builder, "scroll")
+	if err != nil {
+		return
+	}
+	ivv.scroll.Connect("size-allocate", func() {
+		if ivv.fit && ivv.pixbuf != nil && ivv.fitZoom() != ivv.zoom {
+			ivv.render()
+		}
+	})
+	ivv.scroll.Connect("scroll-event", ivv.onScroll)
+
+	ivv.canvas, err = utils.GetUIObject[gtk.EventBox](builder, "canvas")
+	if err != nil {
+		return
+	}
+	ivv.canvas.AddEvents(int(gdk.BUTTON_MOTION_MASK))
+	ivv.canvas.Connect("button-press-event", ivv.onButtonPress)
+	ivv.canvas.Connect("button-release-event", func() {
+		ivv.dragging = false
+	})
+	ivv.canvas.Connect("motion-notify-event", ivv.onMotion)
+
+	ivv.image, err = utils.GetUIObject[gtk.Image](builder, "image")
+	if err != nil {
+		return
+	}
+
+	ivv.position, err = utils.GetUIObject[gtk.Label](builder, "position")
+	if err != nil {
+		return
+	}
+
+	ivv.title, err = utils.GetUIObject[gtk.Label](builder, "title")
+	if err != nil {
+		return
+	}
+
+	ivv.zoomLevel, err = utils.GetUIObject[gtk.Label](builder, "zoomLevel")
+	if err != nil {
+		return
+	}
+
+	ivv.status, err = utils.GetUIObject[gtk.Label](builder, "status")
+	if err != nil {
+		return
+	}
+
+	ivv.previous, err = utils.GetUIObject[gtk.Button](builder, "previous")
+	if err != nil {
+		return
+	}
+	ivv.previous.Connect("clicked", func() {
+		ivv.show(ivv.index - 1)
+	})
+
+	ivv.next, err = utils.GetUIObject[gtk.Button](builder, "next")
+	if err != nil {
+		return
+	}
+	ivv.next.Connect("clicked", func() {
+		ivv.show(ivv.index + 1)
+	})
+
+	ivv.copyButton, err = utils.GetUIObject[gtk.Button](builder, "copy")
+	if err != nil {
+		return
+	}
+	ivv.copyButton.Connect("clicked", ivv.copyImage)
+
+	ivv.saveButton, err = utils.GetUIObject[gtk.Button](builder, "save")
+	if err != nil {
+		return
+	}
+	ivv.saveButton.Connect("clicked", ivv.saveImage)
+
+	buttons := map[string]func(){
+		"zoomIn":       func() { ivv.setZoom(ivv.zoom * viewerZoomStep) },
+		"zoomOut":      func() { ivv.setZoom(ivv.zoom / viewerZoomStep) },
+		"zoomFit":      ivv.zoomToFit,
+		"zoomOriginal": func() { ivv.setZoom(1) },
+		"fullscreen":   func() { ivv.setFullscreen(!ivv.fullscreen) },
+		"close":        ivv.Window.Close,
+	}
+	for buttonName, clicked := range buttons {
+		var button *gtk.Button
+		button, err = utils.GetUIObject[gtk.Button](builder, buttonName)
+		if err != nil {
+			return
+		}
+		button.Connect("clicked", clicked)
+	}
+
+	return
+}
+
+// show loads the original of the image at index, cancelling the load of the
+// one shown before if it didn't finish.
+func (ivv *ImageViewerView) show(index int) {
+	if index < 0 || index >= len(ivv.images) {
+		return
+	}
+	if ivv.cancel != nil {
+		ivv.cancel()
+	}
+
+	ivv.index = index
+	current := ivv.images[index]
+	ivv.Window.SetTitle(current.Title)
+	ivv.title.SetText(current.Title)
+	ivv.position.SetText(fmt.Sprintf("%d / %d", index+1, len(ivv.images)))
+	ivv.position.SetVisible(len(ivv.images) > 1)
+	ivv.previous.SetSensitive(index > 0)
+	ivv.next.SetSensitive(index < len(ivv.images)-1)
+
+	ivv.pixbuf = nil
+	ivv.animation = nil
+	ivv.fit = true
+	ivv.image.Clear()
+	ivv.zoomLevel.SetText("")
+	ivv.copyButton.SetSensitive(false)
+	ivv.saveButton.SetSensitive(false)
+	ivv.status.SetText("Loading…")
+
+	ctx, cancel := context.WithCancel(ivv.ctx)
+	ivv.cancel = cancel
+
+	var taskSequence *utils.TaskSequence[*gdk.PixbufAnimation]
+	taskSequence = utils.NewTaskSequence[*gdk.PixbufAnimation](func() {
+		taskSequence = nil
+	})
+	var pixbuf *gdk.Pixbuf
+	taskSequence.Add(func() (animation *gdk.PixbufAnimation, err error) {
+		animation, pixbuf, err = utils.LoadAnimationFromUrl(ctx, current.URL)
+		return
+	}, func(animation *gdk.PixbufAnimation, err error) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		if err != nil {
+			log.Printf("Couldn't load '%s': %s", current.URL, err)
+			ivv.status.SetText("The image couldn't be loaded.")
+			return false
+		}
+
+		ivv.pixbuf = pixbuf
+		ivv.animation = animation
+		ivv.status.SetText(fmt.Sprintf("%d × %d", pixbuf.GetWidth(), pixbuf.GetHeight()))
+		ivv.copyButton.SetSensitive(true)
+		ivv.saveButton.SetSensitive(true)
+		ivv.render()
+		ivv.preloadNeighbours()
+		return true
+	})
+	taskSequence.Execute()
+}
+
+// preloadNeighbours warms the caches up with the images around the current
+// one, so going through them doesn't wait for the network each time. They
+// aren't tied to the current image, as that's cancelled when moving to them.
+func (ivv *ImageViewerView) preloadNeighbours() {
+	for _, index := range []int{ivv.index + 1, ivv.index - 1} {
+		if index < 0 || index >= len(ivv.images) {
+			continue
+		}
+		go utils.LoadPixmapFromUrl(ivv.ctx, ivv.images[index].URL)
+	}
+}
+
+// render shows the image at the current zoom. Animations can't be scaled, so
+// they only move at their original size.
+func (ivv *ImageViewerView) render() {
+	if ivv.pixbuf == nil {
+		return
+	}
+	if ivv.fit {
+		ivv.zoom = ivv.fitZoom()
+	}
+	ivv.zoomLevel.SetText(fmt.Sprintf("%d%%", int(math.Round(ivv.zoom*100))))
+
+	width := max(1, int(math.Round(float64(ivv.pixbuf.GetWidth())*ivv.zoom)))
+	height := max(1, int(math.Round(float64(ivv.pixbuf.GetHeight())*ivv.zoom)))
+	switch {
+	case width == ivv.pixbuf.GetWidth() && ivv.animation != nil:
+		ivv.image.SetFromAnimation(ivv.animation)
+	case width == ivv.pixbuf.GetWidth():
+		ivv.image.SetFromPixbuf(ivv.pixbuf)
+	default:
+		scaled, err := ivv.pixbuf.ScaleSimple(width, height, gdk.INTERP_BILINEAR)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		ivv.image.SetFromPixbuf(scaled)
+	}
+}
+
+// fitZoom is the zoom showing the whole image in the window, never making it
+// bigger than it is.
+func (ivv *ImageViewerView) fitZoom() float64 {
+	width := float64(ivv.scroll.GetAllocatedWidth())
+	height := float64(ivv.scroll.GetAllocatedHeight())
+	if width <= 1 || height <= 1 {
+		return 1
+	}
+	return math.Min(1, math.Min(width/float64(ivv.pixbuf.GetWidth()), height/float64(ivv.pixbuf.GetHeight())))
+}
+
+// setZoom changes the zoom keeping the point at the center of the window
+// where it is.
+func (ivv *ImageViewerView) setZoom(zoom float64) {
+	if ivv.pixbuf == nil {
+		return
+	}
+	largestSide := float64(max(ivv.pixbuf.GetWidth(), ivv.pixbuf.GetHeight()))
+	zoom = math.Max(minViewerZoom, math.Min(zoom, math.Min(maxViewerZoom, maxViewerImageSide/largestSide)))
+
+	hadjustment := ivv.scroll.GetHAdjustment()
+	vadjustment := ivv.scroll.GetVAdjustment()
+	centerX := adjustmentCenter(hadjustment)
+	centerY := adjustmentCenter(vadjustment)
+
+	ivv.fit = false
+	ivv.zoom = zoom
+	ivv.render()
+
+	// The adjustments only get their new bounds once the image is resized.
+	glib.IdleAdd(func() bool {
+		centerAdjustment(hadjustment, centerX)
+		centerAdjustment(vadjustment, centerY)
+		return false
+	})
+}
+
+func (ivv *ImageViewerView) zoomToFit() {
+	ivv.fit = true
+	ivv.render()
+}
+
+func (ivv *ImageViewerView) setFullscreen(fullscreen bool) {
+	ivv.fullscreen = fullscreen
+	if fullscreen {
+		ivv.Window.Fullscreen()
+	} else {
+		ivv.Window.Unfullscreen()
+	}
+}
+
+func (ivv *ImageViewerView) copyImage() {
+	if ivv.pixbuf == nil {
+		return
+	}
+	clipboard, err := gtk.ClipboardGet(gdk.SELECTION_CLIPBOARD)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	clipboard.SetImage(ivv.pixbuf)
+	ivv.status.SetText("Copied to the clipboard.")
+}
+
+// saveImage writes the image as it was downloaded, not the decoded copy, so
+// nothing is lost by re-encoding it.
+func (ivv *ImageViewerView) saveImage() {
+	current := ivv.images[ivv.index]
+
+	dialog, err := gtk.FileChooserDialogNewWith2Buttons("Save image", ivv.Window, gtk.FILE_CHOOSER_ACTION_SAVE,
+		"Cancel", gtk.RESPONSE_CANCEL, "Save", gtk.RESPONSE_ACCEPT)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	dialog.SetDoOverwriteConfirmation(true)
+	dialog.SetCurrentName(imageFileName(current.URL))
+	response := dialog.Run()
+	filePath := dialog.GetFilename()
+	dialog.Destroy()
+	if response != gtk.RESPONSE_ACCEPT || filePath == "" {
+		return
+	}
+
+	ivv.status.SetText("Saving…")
+	var taskSequence *utils.TaskSequence[string]
+	taskSequence = utils.NewTaskSequence[string](func() {
+		taskSequence = nil
+	})
+	taskSequence.Add(func() (string, error) {
+		return filePath, utils.SaveImageFromUrl(context.Background(), current.URL, filePath)
+	}, func(filePath string, err error) bool {
+		if err != nil {
+			log.Printf("Couldn't save '%s' to '%s': %s", current.URL, filePath, err)
+			utils.ShowError(&ivv.Window.Widget, "The image couldn't be saved: "+err.Error())
+			ivv.status.SetText("")
+			return false
+		}
+		ivv.status.SetText("Saved as " + path.Base(filePath) + ".")
+		return true
+	})
+	taskSequence.Execute()
+}
+
+func (ivv *ImageViewerView) onKeyPress(window *gtk.Window, event *gdk.Event) bool {
+	key := gdk.EventKeyNewFromEvent(event)
+	control := key.State()&gdk.CONTROL_MASK != 0
+
+	switch key.KeyVal() {
+	case gdk.KEY_Escape:
+		ivv.Window.Close()
+	case gdk.KEY_F11:
+		ivv.setFullscreen(!ivv.fullscreen)
+	case gdk.KEY_Left:
+		ivv.show(ivv.index - 1)
+	case gdk.KEY_Right:
+		ivv.show(ivv.index + 1)
+	case gdk.KEY_plus, gdk.KEY_equal, gdk.KEY_KP_Add:
+		ivv.setZoom(ivv.zoom * viewerZoomStep)
+	case gdk.KEY_minus, gdk.KEY_KP_Subtract:
+		ivv.setZoom(ivv.zoom / viewerZoomStep)
+	case gdk.KEY_0:
+		ivv.zoomToFit()
+	case gdk.KEY_1:
+		ivv.setZoom(1)
+	case gdk.KEY_c:
+		if !control {
+			return false
+		}
+		ivv.copyImage()
+	case gdk.KEY_s:
+		if !control || ivv.pixbuf == nil {
+			return false
+		}
+		ivv.saveImage()
+	default:
+		return false
+	}
+	return true
+}
+
+// onScroll zooms with Ctrl and the wheel, leaving plain scrolling alone.
+func (ivv *ImageViewerView) onScroll(scroll *gtk.ScrolledWindow, event *gdk.Event) bool {
+	scrollEvent := gdk.EventScrollNewFromEvent(event)
+	if scrollEvent.State()&gdk.CONTROL_MASK == 0 {
+		return false
+	}
+
+	switch {
+	case scrollEvent.Direction() == gdk.SCROLL_UP,
+		scrollEvent.Direction() == gdk.SCROLL_SMOOTH && scrollEvent.DeltaY() < 0:
+		ivv.setZoom(ivv.zoom * viewerZoomStep)
+	case scrollEvent.Direction() == gdk.SCROLL_DOWN,
+		scrollEvent.Direction() == gdk.SCROLL_SMOOTH && scrollEvent.DeltaY() > 0:
+		ivv.setZoom(ivv.zoom / viewerZoomStep)
+	}
+	return true
+}
+
+// onButtonPress starts dragging the image around, or switches between fitting
+// it and its original size on double click.
+func (ivv *ImageViewerView) onButtonPress(canvas *gtk.EventBox, event *gdk.Event) bool {
+	button := gdk.EventButtonNewFromEvent(event)
+	if button.Button() != gdk.BUTTON_PRIMARY {
+		return false
+	}
+
+	if button.Type() == gdk.EVENT_2BUTTON_PRESS {
+		ivv.dragging = false
+		if ivv.fit {
+			ivv.setZoom(1)
+		} else {
+			ivv.zoomToFit()
+		}
+		return true
+	}
+
+	ivv.dragging = true
+	ivv.dragStart[0], ivv.dragStart[1] = button.MotionValRoot()
+	ivv.dragScroll[0] = ivv.scroll.GetHAdjustment().GetValue()
+	ivv.dragScroll[1] = ivv.scroll.GetVAdjustment().GetValue()
+	return true
+}
+
+func (ivv *ImageViewerView) onMotion(canvas *gtk.EventBox, event *gdk.Event) bool {
+	if !ivv.dragging {
+		return false
+	}
+	x, y := gdk.EventMotionNewFromEvent(event).MotionValRoot()
+	ivv.scroll.GetHAdjustment().SetValue(ivv.dragScroll[0] - (x - ivv.dragStart[0]))
+	ivv.scroll.GetVAdjustment().SetValue(ivv.dragScroll[1] - (y - ivv.dragStart[1]))
+	return true
+}
+
+// adjustmentCenter is where the middle of the visible area is, relative to
+// the whole scrollable size.
+func adjustmentCenter(adjustment *gtk.Adjustment) float64 {
+	if adjustment.GetUpper() <= 0 {
+		return 0.5
+	}
+	return (adjustment.GetValue() + adjustment.GetPageSize()/2) / adjustment.GetUpper()
+}
+
+func centerAdjustment(adjustment *gtk.Adjustment, center float64) {
+	adjustment.SetValue(center*adjustment.GetUpper() - adjustment.GetPageSize()/2)
+}
+
+// imageFileName suggests a name to save the image at imageUrl with.
+func imageFileName(imageUrl string) string {
+	parsed, err := url.Parse(imageUrl)
+	if err == nil {
+		if name := path.Base(parsed.Path); name != "." && name != "/" {
+			return name
+		}
+	}
+	return "image"
+}
@@ -0,0 +1,132 @@
+package view
+
+import (
+	"time"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+type InboxItemView struct {
+	ItemBox         *gtk.Box
+	OpenClicked     func(model.InboxItem)
+	MarkReadClicked func(model.InboxItem)
+
+	item     model.InboxItem
+	kind     *gtk.Label
+	author   *gtk.Label
+	time     *gtk.Label
+	title    *gtk.Label
+	content  *gtk.Label
+	markdown *MarkdownLabel
+	open     *gtk.Button
+	markRead *gtk.Button
+}
+
+func NewInboxItemView(item model.InboxItem) (iiv *InboxItemView, err error) {
+	iiv = &InboxItemView{}
+	_, err = iiv.buildAndSetReferences()
+	if err != nil {
+		return
+	}
+
+	iiv.fillItemData(item)
+
+	return
+}
+
+func (iiv *InboxItemView) SetRead() {
+	iiv.item.Read = true
+	iiv.markRead.Hide()
+}
+
+func (iiv *InboxItemView) SetBusy(busy bool) {
+	iiv.markRead.SetSensitive(!busy)
+}
+
+func (iiv *InboxItemView) buildAndSetReferences() (builder *gtk.Builder, err error) {
+	builder, err = gtk.BuilderNewFromString(string(data.InboxItemUI))
+	if err != nil {
+		return
+	}
+
+	utils.SetWidgetProperty(builder, "card", func(card *gtk.Box) {
+		utils.ApplyStyle(&card.Widget)
+	})
+
+	iiv.kind, err = utils.GetUIObject[gtk.Label](builder, "kind")
+	if err != nil {
+		return
+	}
+
+	iiv.author, err = utils.GetUIObject[gtk.Label](builder, "author")
+	if err != nil {
+		return
+	}
+
+	iiv.time, err = utils.GetUIObject[gtk.Label](builder, "time")
+	if err != nil {
+		return
+	}
+
+	iiv.title, err = utils.GetUIObject[gtk.Label](builder, "title")
+	if err != nil {
+		return
+	}
+
+	iiv.content, err = utils.GetUIObject[gtk.Label](builder, "content")
+	if err != nil {
+		return
+	}
+	iiv.markdown = NewMarkdownLabel(iiv.content)
+
+	iiv.open, err = utils.GetUIObject[gtk.Button](builder, "open")
+	if err != nil {
+		return
+	}
+	iiv.open.Connect("clicked", func() {
+		if iiv.OpenClicked != nil {
+			iiv.OpenClicked(iiv.item)
+		}
+	})
+
+	iiv.markRead, err = utils.GetUIObject[gtk.Button](builder, "markRead")
+	if err != nil {
+		return
+	}
+	iiv.markRead.Connect("clicked", func() {
+		if iiv.MarkReadClicked != nil {
+			iiv.MarkReadClicked(iiv.item)
+		}
+	})
+
+	iiv.ItemBox, err = utils.GetUIObject[gtk.Box](builder, "inboxItem")
+	if err != nil {
+		return
+	}
+	iiv.ItemBox.Unparent()
+
+	return
+}
+
+func (iiv *InboxItemView) fillItemData(item model.InboxItem) {
+	iiv.item = item
+
+	switch item.Kind {
+	case model.InboxItemReply:
+		iiv.kind.SetText("Reply")
+	case model.InboxItemMention:
+		iiv.kind.SetText("Mention")
+	case model.InboxItemMessage:
+		iiv.kind.SetText("Message")
+		iiv.open.SetLabel("Open conversation")
+	}
+
+	iiv.author.SetText(item.Author.DisplayName.ValueOr(item.Author.Name))
+	iiv.time.SetText(utils.GetNiceDuration(time.Since(item.Published)))
+	iiv.title.SetText(item.Title)
+	iiv.markdown.SetMarkdown(item.Content)
+	iiv.markRead.SetVisible(!item.Read)
+}
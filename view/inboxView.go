@@ -0,0 +1,144 @@
+package view
+
+import (
+	"log"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+type InboxView struct {
+	UnreadOnlyToggled  func(bool)
+	MarkAllReadClicked func()
+	ItemOpened         func(model.InboxItem)
+	ItemMarkRead       func(model.InboxItem)
+
+	itemViews   []*InboxItemView
+	inboxBox    *gtk.Box
+	unreadOnly  *gtk.CheckButton
+	markAllRead *gtk.Button
+	empty       *gtk.Label
+	itemsBox    *gtk.Box
+}
+
+func (iv *InboxView) SetupInboxView(box *gtk.Box, unreadOnly bool) (err error) {
+	_, err = iv.buildAndSetReferences()
+	if err != nil {
+		return
+	}
+
+	iv.itemViews = make([]*InboxItemView, 0)
+	iv.unreadOnly.SetActive(unreadOnly)
+	iv.unreadOnly.Connect("toggled", func() {
+		if iv.UnreadOnlyToggled != nil {
+			iv.UnreadOnlyToggled(iv.unreadOnly.GetActive())
+		}
+	})
+
+	box.PackStart(iv.inboxBox, true, true, 0)
+
+	return
+}
+
+func (iv *InboxView) CleanView() {
+	iv.itemViews = make([]*InboxItemView, 0)
+	iv.empty.Hide()
+	removeAllChildren(&iv.itemsBox.Container)
+}
+
+func (iv *InboxView) FillItems(items []model.InboxItem) {
+	for _, item := range items {
+		itemView, err := NewInboxItemView(item)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		itemView.OpenClicked = func(item model.InboxItem) {
+			if iv.ItemOpened != nil {
+				iv.ItemOpened(item)
+			}
+		}
+		itemView.MarkReadClicked = func(item model.InboxItem) {
+			if iv.ItemMarkRead != nil {
+				iv.ItemMarkRead(item)
+			}
+		}
+
+		iv.itemViews = append(iv.itemViews, itemView)
+		iv.itemsBox.PackStart(itemView.ItemBox, false, false, 0)
+	}
+}
+
+func (iv *InboxView) ShowEmptyIfNoItems() {
+	iv.empty.SetVisible(len(iv.itemViews) == 0)
+}
+
+func (iv *InboxView) SetItemRead(kind model.InboxItemKind, id int64) {
+	if itemView := iv.findItemView(kind, id); itemView != nil {
+		itemView.SetRead()
+	}
+}
+
+func (iv *InboxView) SetItemBusy(kind model.InboxItemKind, id int64, busy bool) {
+	if itemView := iv.findItemView(kind, id); itemView != nil {
+		itemView.SetBusy(busy)
+	}
+}
+
+func (iv *InboxView) SetAllRead() {
+	for _, itemView := range iv.itemViews {
+		itemView.SetRead()
+	}
+}
+
+func (iv *InboxView) findItemView(kind model.InboxItemKind, id int64) *InboxItemView {
+	for _, itemView := range iv.itemViews {
+		if itemView.item.Kind == kind && itemView.item.ID == id {
+			return itemView
+		}
+	}
+	return nil
+}
+
+func (iv *InboxView) buildAndSetReferences() (builder *gtk.Builder, err error) {
+	builder, err = gtk.BuilderNewFromString(string(data.InboxUI))
+	if err != nil {
+		return
+	}
+
+	iv.unreadOnly, err = utils.GetUIObject[gtk.CheckButton](builder, "unreadOnly")
+	if err != nil {
+		return
+	}
+
+	iv.markAllRead, err = utils.GetUIObject[gtk.Button](builder, "markAllRead")
+	if err != nil {
+		return
+	}
+	iv.markAllRead.Connect("clicked", func() {
+		if iv.MarkAllReadClicked != nil {
+			iv.MarkAllReadClicked()
+		}
+	})
+
+	iv.empty, err = utils.GetUIObject[gtk.Label](builder, "empty")
+	if err != nil {
+		return
+	}
+
+	iv.itemsBox, err = utils.GetUIObject[gtk.Box](builder, "items")
+	if err != nil {
+		return
+	}
+
+	iv.inboxBox, err = utils.GetUIObject[gtk.Box](builder, "inbox")
+	if err != nil {
+		return
+	}
+	iv.inboxBox.Unparent()
+
+	return
+}
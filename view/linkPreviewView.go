@@ -0,0 +1,102 @@
+package view
+
+import (
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+const faviconSize = 16
+
+// LinkPreviewView is a card describing the page a post links to, opening it
+// when clicked.
+type LinkPreviewView struct {
+	LinkPreview *gtk.Box
+
+	page        *gtk.LinkButton
+	favicon     *gtk.Image
+	siteName    *gtk.Label
+	title       *gtk.Label
+	description *gtk.Label
+	video       *gtk.LinkButton
+}
+
+func NewLinkPreviewView(preview utils.LinkPreview, favicon *gdk.Pixbuf) (lpv *LinkPreviewView, err error) {
+	lpv = &LinkPreviewView{}
+	_, err = lpv.buildAndSetReferences()
+	if err != nil {
+		return
+	}
+
+	lpv.page.SetUri(preview.URL)
+	lpv.siteName.SetText(preview.SiteName)
+	if favicon != nil {
+		utils.SetDirectImage(lpv.favicon, favicon, [2]int{faviconSize, faviconSize}, nil)
+	}
+
+	if preview.Title != "" {
+		lpv.title.SetText(preview.Title)
+	} else {
+		lpv.title.Hide()
+	}
+	if preview.Description != "" {
+		lpv.description.SetText(preview.Description)
+	} else {
+		lpv.description.Hide()
+	}
+
+	if preview.VideoURL != "" {
+		lpv.video.SetUri(preview.VideoURL)
+		lpv.video.Show()
+	}
+
+	return
+}
+
+func (lpv *LinkPreviewView) buildAndSetReferences() (builder *gtk.Builder, err error) {
+	builder, err = gtk.BuilderNewFromString(string(data.LinkPreviewUI))
+	if err != nil {
+		return
+	}
+
+	lpv.LinkPreview, err = utils.GetUIObject[gtk.Box](builder, "linkPreview")
+	if err != nil {
+		return
+	}
+
+	lpv.page, err = utils.GetUIObject[gtk.LinkButton](builder, "page")
+	if err != nil {
+		return
+	}
+	utils.ApplyStyle(&lpv.page.Widget)
+
+	lpv.favicon, err = utils.GetUIObject[gtk.Image](builder, "favicon")
+	if err != nil {
+		return
+	}
+
+	lpv.siteName, err = utils.GetUIObject[gtk.Label](builder, "siteName")
+	if err != nil {
+		return
+	}
+
+	lpv.title, err = utils.GetUIObject[gtk.Label](builder, "title")
+	if err != nil {
+		return
+	}
+
+	lpv.description, err = utils.GetUIObject[gtk.Label](builder, "description")
+	if err != nil {
+		return
+	}
+
+	lpv.video, err = utils.GetUIObject[gtk.LinkButton](builder, "video")
+	if err != nil {
+		return
+	}
+
+	lpv.LinkPreview.Unparent()
+
+	return
+}
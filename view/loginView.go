@@ -1,21 +1,36 @@
 package view
 
 import (
+	"fmt"
+	"html"
 	"log"
 
 	"github.com/gotk3/gotk3/gtk"
 	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/model"
 	"github.com/mjdiliscia/LemmeRead/utils"
 )
 
 type LoginView struct {
-	LoginClicked func(string, string, string)
-	Window       *gtk.Dialog
+	LoginClicked  func(string, string, string, string)
+	ServerChanged func(string)
+	SignUpClicked func(string)
+	BrowseClicked func(string)
+	Window        *gtk.Dialog
 
-	server   *gtk.Entry
-	username *gtk.Entry
-	password *gtk.Entry
-	login    *gtk.Button
+	checkedServer string
+	server        *gtk.Entry
+	instanceInfo  *gtk.Box
+	instanceIcon  *gtk.Image
+	instanceLabel *gtk.Label
+	signUp        *gtk.Button
+	browse        *gtk.Button
+	username      *gtk.Entry
+	password      *gtk.Entry
+	totpLabel     *gtk.Label
+	totp          *gtk.Entry
+	status        *gtk.Label
+	login         *gtk.Button
 }
 
 func (lv *LoginView) SetupLoginView() (err error) {
@@ -28,20 +43,56 @@ func (lv *LoginView) SetupLoginView() (err error) {
 		if lv.LoginClicked != nil {
 			server, err := lv.server.GetText()
 			if err != nil {
-				log.Panic(err)
+				log.Println(err)
+				return
 			}
 
 			username, err := lv.username.GetText()
 			if err != nil {
-				log.Panic(err)
+				log.Println(err)
+				return
 			}
 
 			password, err := lv.password.GetText()
 			if err != nil {
-				log.Panic(err)
+				log.Println(err)
+				return
 			}
 
-			lv.LoginClicked(server, username, password)
+			totp, err := lv.totp.GetText()
+			if err != nil {
+				log.Println(err)
+				return
+			}
+
+			lv.SetBusy(true)
+			lv.LoginClicked(server, username, password, totp)
+		}
+	})
+
+	lv.server.Connect("changed", func() {
+		lv.checkedServer = ""
+		lv.instanceInfo.Hide()
+		lv.signUp.SetSensitive(false)
+		lv.browse.SetSensitive(false)
+	})
+	lv.server.Connect("activate", lv.checkServer)
+	lv.server.Connect("focus-out-event", func() bool {
+		lv.checkServer()
+		return false
+	})
+
+	lv.signUp.SetSensitive(false)
+	lv.signUp.Connect("clicked", func() {
+		if lv.SignUpClicked != nil && lv.checkedServer != "" {
+			lv.SignUpClicked(lv.checkedServer)
+		}
+	})
+
+	lv.browse.SetSensitive(false)
+	lv.browse.Connect("clicked", func() {
+		if lv.BrowseClicked != nil && lv.checkedServer != "" {
+			lv.BrowseClicked(lv.checkedServer)
 		}
 	})
 
@@ -50,6 +101,100 @@ func (lv *LoginView) SetupLoginView() (err error) {
 	return nil
 }
 
+// SetServer prefills the dialog, to log in again into a known account.
+func (lv *LoginView) SetServer(server string, username string) {
+	lv.server.SetText(server)
+	lv.username.SetText(username)
+	if server != "" {
+		lv.checkServer()
+	}
+	if username != "" {
+		lv.password.GrabFocus()
+	}
+}
+
+// SetInstance shows what the server entry points to. Only the latest server
+// typed is taken into account, late answers for previous ones are ignored.
+func (lv *LoginView) SetInstance(instance model.InstanceModel) {
+	if instance.URL != lv.checkedServer {
+		return
+	}
+
+	if instance.Icon != nil {
+		utils.SetDirectImage(lv.instanceIcon, instance.Icon, [2]int{32, 32}, nil)
+	} else {
+		lv.instanceIcon.Hide()
+	}
+
+	text := fmt.Sprintf("<b>%s</b>\nLemmy %s", html.EscapeString(instance.Name), html.EscapeString(instance.Version))
+	if !instance.RegistrationOpen() {
+		text += " · registration closed"
+	}
+	lv.instanceLabel.SetMarkup(text)
+	lv.instanceInfo.Show()
+	lv.signUp.SetSensitive(instance.RegistrationOpen())
+	lv.browse.SetSensitive(true)
+}
+
+func (lv *LoginView) SetInstanceError(server string, message string) {
+	if server != lv.checkedServer {
+		return
+	}
+
+	lv.instanceIcon.Hide()
+	lv.instanceLabel.SetText(message)
+	lv.instanceInfo.Show()
+	lv.signUp.SetSensitive(false)
+	lv.browse.SetSensitive(false)
+}
+
+func (lv *LoginView) SetBusy(busy bool) {
+	lv.login.SetSensitive(!busy)
+	if busy {
+		lv.ShowMessage("Logging in...")
+	}
+}
+
+func (lv *LoginView) ShowMessage(message string) {
+	lv.status.SetText(message)
+	lv.status.SetVisible(message != "")
+}
+
+// AskForTOTP reveals the two-factor code entry, which stays hidden for accounts
+// that don't need it.
+func (lv *LoginView) AskForTOTP(message string) {
+	lv.totpLabel.Show()
+	lv.totp.Show()
+	lv.totp.SetText("")
+	lv.totp.GrabFocus()
+	lv.ShowMessage(message)
+}
+
+func (lv *LoginView) checkServer() {
+	text, err := lv.server.GetText()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	server, err := model.NormalizeInstanceURL(text)
+	if err != nil {
+		if text != "" {
+			lv.checkedServer = text
+			lv.SetInstanceError(text, err.Error())
+		}
+		return
+	}
+	if server == lv.checkedServer {
+		return
+	}
+
+	lv.checkedServer = server
+	if lv.ServerChanged != nil {
+		lv.ServerChanged(server)
+	}
+}
+
 func (lv *LoginView) buildAndSetReferences() (builder *gtk.Builder, err error) {
 	builder, err = gtk.BuilderNewFromString(string(data.LoginUI))
 	if err != nil {
@@ -71,6 +216,31 @@ func (lv *LoginView) buildAndSetReferences() (builder *gtk.Builder, err error) {
 		return
 	}
 
+	lv.instanceInfo, err = utils.GetUIObject[gtk.Box](builder, "instanceInfo")
+	if err != nil {
+		return
+	}
+
+	lv.instanceIcon, err = utils.GetUIObject[gtk.Image](builder, "instanceIcon")
+	if err != nil {
+		return
+	}
+
+	lv.instanceLabel, err = utils.GetUIObject[gtk.Label](builder, "instanceLabel")
+	if err != nil {
+		return
+	}
+
+	lv.signUp, err = utils.GetUIObject[gtk.Button](builder, "signUp")
+	if err != nil {
+		return
+	}
+
+	lv.browse, err = utils.GetUIObject[gtk.Button](builder, "browse")
+	if err != nil {
+		return
+	}
+
 	lv.username, err = utils.GetUIObject[gtk.Entry](builder, "username")
 	if err != nil {
 		return
@@ -81,10 +251,28 @@ func (lv *LoginView) buildAndSetReferences() (builder *gtk.Builder, err error) {
 		return
 	}
 
+	lv.totpLabel, err = utils.GetUIObject[gtk.Label](builder, "totpLabel")
+	if err != nil {
+		return
+	}
+
+	lv.totp, err = utils.GetUIObject[gtk.Entry](builder, "totp")
+	if err != nil {
+		return
+	}
+
+	lv.status, err = utils.GetUIObject[gtk.Label](builder, "status")
+	if err != nil {
+		return
+	}
+
 	return
 }
 
 func (lv *LoginView) DestroyWindow() {
 	lv.Window.Destroy()
 	lv.LoginClicked = nil
+	lv.ServerChanged = nil
+	lv.SignUpClicked = nil
+	lv.BrowseClicked = nil
 }
@@ -1,9 +1,13 @@
 package view
 
 import (
+	"fmt"
 	"log"
+	"net/url"
 	"strconv"
+	"strings"
 
+	"github.com/gotk3/gotk3/glib"
 	"github.com/gotk3/gotk3/gtk"
 	"github.com/mjdiliscia/LemmeRead/data"
 	"github.com/mjdiliscia/LemmeRead/model"
@@ -17,30 +21,119 @@ const (
 )
 
 type MainView struct {
-	Window                *gtk.ApplicationWindow
-	Model                 *model.AppModel
-	PostListView          PostListView
-	PostView              *PostView
-	PostListBottomReached func()
-	CloseCommentsClicked  func()
-	OrderChanged          func(int)
-	FilterChanged         func(int)
-
-	stack          *gtk.Stack
-	postListBox    *gtk.Box
-	postListScroll *gtk.ScrolledWindow
-	postBox        *gtk.Box
-	postScroll     *gtk.ScrolledWindow
-	closeComments  *gtk.Button
-	search         *gtk.Button
-	menu           *gtk.MenuButton
-	orderItems     map[int]*gtk.RadioMenuItem
-	filterItems    map[int]*gtk.RadioMenuItem
+	Window                     *gtk.ApplicationWindow
+	Model                      *model.AppModel
+	PostListView               PostListView
+	PostView                   *PostView
+	SearchView                 SearchView
+	CommunityListView          *CommunityListView
+	CommunityPageView          *CommunityPageView
+	ProfileView                *ProfileView
+	SavedView                  *SavedView
+	InboxView                  *InboxView
+	ConversationListView       *ConversationListView
+	ConversationView           *ConversationView
+	PostListBottomReached      func()
+	SearchBottomReached        func()
+	SearchActivated            func(string)
+	CommentContextClicked      func(int64)
+	BackClicked                func()
+	OrderChanged               func(int)
+	FilterChanged              func(int)
+	PostVotesChanged           func(int64, int64)
+	CommentVotesChanged        func(int64, int64, int64)
+	PostSaveClicked            func(int64, bool)
+	PostMediaNeeded            func(int64)
+	CommentSaveClicked         func(int64, int64, bool)
+	CommentSubmitted           func(int64, int64, string)
+	CommentEdited              func(int64, int64, string)
+	CommentDeleted             func(int64, int64)
+	NewPostClicked             func()
+	CommunitiesClicked         func()
+	CommunitiesBottomReached   func()
+	CommunitiesOrderChanged    func(int)
+	CommunitiesFilterChanged   func(int)
+	SubscribeClicked           func(int64, bool)
+	ShowCommunityPostsClicked  func(int64)
+	CommunityClicked           func(int64)
+	CommunityPageBottomReached func()
+	UserClicked                func(int64)
+	ProfileBottomReached       func()
+	SavedClicked               func()
+	SavedBottomReached         func()
+	InboxClicked               func()
+	InboxBottomReached         func()
+	InboxUnreadOnlyToggled     func(bool)
+	InboxMarkAllReadClicked    func()
+	InboxItemOpened            func(model.InboxItem)
+	InboxItemMarkRead          func(model.InboxItem)
+	MessagesClicked            func()
+	MessagesBottomReached      func()
+	ConversationClicked        func(int64)
+	MessageUserClicked         func(int64)
+	MessageSent                func(int64, string)
+	MessageEdited              func(int64, string)
+	MessageDeleted             func(int64)
+	AccountSelected            func(int)
+	AddAccountClicked          func()
+	LogInClicked               func()
+	ClearCacheClicked          func()
+
+	pages           []mainViewPage
+	stack           *gtk.Stack
+	postListBox     *gtk.Box
+	postListScroll  *gtk.ScrolledWindow
+	postBox         *gtk.Box
+	postScroll      *gtk.ScrolledWindow
+	searchBox       *gtk.Box
+	searchScroll    *gtk.ScrolledWindow
+	closeComments   *gtk.Button
+	search          *gtk.Button
+	searchPopover   *gtk.Popover
+	searchEntry     *gtk.SearchEntry
+	newPost         *gtk.Button
+	headerBar       *gtk.HeaderBar
+	inbox           *gtk.Button
+	inboxImg        *gtk.Image
+	inboxCount      *gtk.Label
+	logIn           *gtk.Button
+	infoBar         *gtk.InfoBar
+	infoLabel       *gtk.Label
+	infoAction      *gtk.Button
+	onInfoAction    func()
+	communitiesItem *gtk.MenuItem
+	savedItem       *gtk.MenuItem
+	messagesItem    *gtk.MenuItem
+	clearCacheItem  *gtk.MenuItem
+	mainMenu        *gtk.Menu
+	menu            *gtk.MenuButton
+	orderItems      map[int]*gtk.RadioMenuItem
+	filterItems     map[int]*gtk.RadioMenuItem
+	accountsMenu    *gtk.Menu
+	syncingMenus    bool
+}
+
+type mainViewPage struct {
+	container *gtk.Container
+	onClose   func()
 }
 
 func (mv *MainView) SetupMainView(appModel *model.AppModel) (err error) {
 	mv.Model = appModel
 	mv.Model.NewPosts = mv.onNewPosts
+	mv.Model.Search.NewPosts = mv.onNewSearchPosts
+	mv.Model.Search.NewResults = mv.onNewSearchResults
+	mv.Model.Communities.NewCommunities = mv.onNewCommunities
+	mv.Model.CommunityPage.NewPosts = mv.onNewCommunityPagePosts
+	mv.Model.Profile.NewPosts = mv.onNewProfilePosts
+	mv.Model.Profile.NewPerson = mv.onNewProfilePerson
+	mv.Model.Profile.NewComments = mv.onNewProfileComments
+	mv.Model.Saved.NewPosts = mv.onNewSavedPosts
+	mv.Model.Saved.NewComments = mv.onNewSavedComments
+	mv.Model.Inbox.NewItems = mv.onNewInboxItems
+	mv.Model.Inbox.UnreadChanged = mv.onInboxUnreadChanged
+	mv.Model.Messages.NewConversations = mv.onNewConversations
+	mv.Model.Messages.ConversationChanged = mv.onConversationChanged
 
 	_, err = mv.buildAndSetReferences()
 	if err != nil {
@@ -52,49 +145,274 @@ func (mv *MainView) SetupMainView(appModel *model.AppModel) (err error) {
 		return
 	}
 
+	mv.pages = []mainViewPage{{container: &mv.postListScroll.Container}}
+
+	mv.PostListView.VotesChanged = func(postID int64, score int64) {
+		if mv.PostVotesChanged != nil {
+			mv.PostVotesChanged(postID, score)
+		}
+	}
+	mv.PostListView.SaveClicked = mv.onPostSaveClicked
+	mv.PostListView.MediaNeeded = mv.onPostMediaNeeded
+	mv.PostListView.CommunityClicked = mv.onCommunityClicked
+	mv.PostListView.UserClicked = mv.onUserClicked
+
+	err = mv.SearchView.SetupSearchView(mv.searchBox)
+	if err != nil {
+		return
+	}
+
+	mv.SearchView.PostListView.VotesChanged = func(postID int64, score int64) {
+		if mv.PostVotesChanged != nil {
+			mv.PostVotesChanged(postID, score)
+		}
+	}
+
+	mv.SearchView.PostListView.SaveClicked = mv.onPostSaveClicked
+	mv.SearchView.PostListView.MediaNeeded = mv.onPostMediaNeeded
+	mv.SearchView.PostListView.CommunityClicked = mv.onCommunityClicked
+	mv.SearchView.PostListView.UserClicked = mv.onUserClicked
+
+	mv.SearchView.CommentContextClicked = func(postID int64) {
+		if mv.CommentContextClicked != nil {
+			mv.CommentContextClicked(postID)
+		}
+	}
+
 	mv.postListScroll.Connect("edge-reached", func(scroll *gtk.ScrolledWindow, position gtk.PositionType) {
 		if position == gtk.POS_BOTTOM && mv.PostListBottomReached != nil {
 			mv.PostListBottomReached()
 		}
 	})
 
+	mv.searchScroll.Connect("edge-reached", func(scroll *gtk.ScrolledWindow, position gtk.PositionType) {
+		if position == gtk.POS_BOTTOM && mv.SearchBottomReached != nil {
+			mv.SearchBottomReached()
+		}
+	})
+
 	mv.closeComments.Connect("clicked", func() {
-		if mv.CloseCommentsClicked != nil {
-			mv.CloseCommentsClicked()
+		if mv.BackClicked != nil {
+			mv.BackClicked()
 		}
 	})
 
-	for index, orderItem := range mv.orderItems {
-		orderItem.SetActive(index == int(mv.Model.Configuration.GetOrder()))
+	mv.searchPopover.SetRelativeTo(mv.search)
+	mv.search.Connect("clicked", func() {
+		mv.searchPopover.Popup()
+		mv.searchEntry.GrabFocus()
+	})
+
+	mv.searchEntry.Connect("activate", func() {
+		query, err := mv.searchEntry.GetText()
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		mv.searchPopover.Popdown()
+		if strings.TrimSpace(query) != "" && mv.SearchActivated != nil {
+			mv.SearchActivated(strings.TrimSpace(query))
+		}
+	})
+
+	mv.inbox.Connect("clicked", func() {
+		if mv.InboxClicked != nil {
+			mv.InboxClicked()
+		}
+	})
+
+	mv.infoBar.Connect("response", func() {
+		mv.HideNotice()
+	})
+	mv.infoAction.Connect("clicked", func() {
+		action := mv.onInfoAction
+		mv.HideNotice()
+		if action != nil {
+			action()
+		}
+	})
+
+	mv.logIn.Connect("clicked", func() {
+		if mv.LogInClicked != nil {
+			mv.LogInClicked()
+		}
+	})
+
+	mv.newPost.Connect("clicked", func() {
+		if mv.NewPostClicked != nil {
+			mv.NewPostClicked()
+		}
+	})
+
+	mv.communitiesItem.Connect("activate", func() {
+		if mv.CommunitiesClicked != nil {
+			mv.CommunitiesClicked()
+		}
+	})
+
+	mv.savedItem.Connect("activate", func() {
+		if mv.SavedClicked != nil {
+			mv.SavedClicked()
+		}
+	})
+
+	mv.messagesItem.Connect("activate", func() {
+		if mv.MessagesClicked != nil {
+			mv.MessagesClicked()
+		}
+	})
+
+	mv.mainMenu.Connect("show", func() {
+		mv.clearCacheItem.SetLabel(fmt.Sprintf("Clear image cache (%.1f MB)", float64(mv.Model.ImageCacheSize())/(1<<20)))
+	})
+	mv.clearCacheItem.Connect("activate", func() {
+		if mv.ClearCacheClicked != nil {
+			mv.ClearCacheClicked()
+		}
+	})
 
+	for index, orderItem := range mv.orderItems {
 		idx, item := index, orderItem
 		orderItem.Connect("activate", func() {
-			if item.GetActive() && mv.OrderChanged != nil {
+			if !mv.syncingMenus && item.GetActive() && mv.OrderChanged != nil {
 				mv.OrderChanged(idx)
 			}
 		})
 	}
 
 	for index, filterItem := range mv.filterItems {
-		filterItem.SetActive(index == int(mv.Model.Configuration.GetFilter()))
-
 		idx, item := index, filterItem
 		filterItem.Connect("activate", func() {
-			if item.GetActive() && mv.FilterChanged != nil {
+			if !mv.syncingMenus && item.GetActive() && mv.FilterChanged != nil {
 				mv.FilterChanged(idx)
 			}
 		})
 	}
 
+	mv.syncFeedMenus()
+	mv.RefreshAccounts()
+
 	mv.Window.Show()
+	mv.syncAnonymous()
 
 	return nil
 }
 
+// ShowError tells about a failed request in a bar over the current page,
+// offering to try it again when retry isn't nil.
+func (mv *MainView) ShowError(message string, retry func()) {
+	mv.ShowNotice(gtk.MESSAGE_ERROR, message, "Retry", retry)
+}
+
+// ShowNotice replaces whatever the info bar was showing. The action button is
+// only shown when there's an action to run.
+func (mv *MainView) ShowNotice(messageType gtk.MessageType, message string, actionLabel string, action func()) {
+	mv.onInfoAction = action
+	mv.infoBar.SetMessageType(messageType)
+	mv.infoLabel.SetText(message)
+	mv.infoAction.SetLabel(actionLabel)
+	mv.infoAction.SetVisible(action != nil)
+	mv.infoBar.Show()
+}
+
+func (mv *MainView) HideNotice() {
+	mv.onInfoAction = nil
+	mv.infoBar.Hide()
+}
+
+func (mv *MainView) ScrollToTop() {
+	adjustment := mv.postListScroll.GetVAdjustment()
+	adjustment.SetValue(adjustment.GetLower())
+}
+
 func (mv *MainView) CleanView() {
 	mv.PostListView.CleanView()
 }
 
+// ResetView brings the window back to an empty feed for a freshly switched account.
+func (mv *MainView) ResetView() {
+	mv.HideNotice()
+	mv.GoBackToRoot()
+	mv.CleanView()
+	mv.SetFeedSubtitle("")
+	mv.syncFeedMenus()
+	mv.syncAnonymous()
+	mv.RefreshAccounts()
+	mv.ScrollToTop()
+}
+
+func (mv *MainView) RefreshAccounts() {
+	removeAllChildren(&mv.accountsMenu.Container)
+
+	var group *gtk.RadioMenuItem
+	current := mv.Model.Configuration.GetCurrentAccount()
+	for index, account := range mv.Model.Configuration.GetAccounts() {
+		item, err := gtk.RadioMenuItemNewWithLabelFromWidget(group, accountLabel(account))
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		group = item
+		item.SetActive(index == current)
+
+		idx := index
+		item.Connect("activate", func() {
+			if item.GetActive() && idx != mv.Model.Configuration.GetCurrentAccount() && mv.AccountSelected != nil {
+				mv.AccountSelected(idx)
+			}
+		})
+		mv.accountsMenu.Append(item)
+	}
+
+	separator, err := gtk.SeparatorMenuItemNew()
+	if err == nil {
+		mv.accountsMenu.Append(separator)
+	}
+
+	addLabel := "Add account..."
+	if mv.Model.Configuration.IsAnonymous() {
+		addLabel = "Log in..."
+	}
+	addItem, err := gtk.MenuItemNewWithLabel(addLabel)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	addItem.Connect("activate", func() {
+		if mv.AddAccountClicked != nil {
+			mv.AddAccountClicked()
+		}
+	})
+	mv.accountsMenu.Append(addItem)
+	mv.accountsMenu.ShowAll()
+}
+
+func (mv *MainView) syncFeedMenus() {
+	mv.syncingMenus = true
+	for index, orderItem := range mv.orderItems {
+		orderItem.SetActive(index == int(mv.Model.Configuration.GetOrder()))
+	}
+	for index, filterItem := range mv.filterItems {
+		filterItem.SetActive(index == int(mv.Model.Configuration.GetFilter()))
+	}
+	mv.syncingMenus = false
+}
+
+// syncAnonymous hides everything that needs an account while browsing
+// anonymously, and shows it back once logged in.
+func (mv *MainView) syncAnonymous() {
+	anonymous := mv.Model.Configuration.IsAnonymous()
+	mv.PostListView.ReadOnly = anonymous
+	mv.SearchView.PostListView.ReadOnly = anonymous
+
+	mv.logIn.SetVisible(anonymous)
+	mv.updateHeader()
+	mv.savedItem.SetVisible(!anonymous)
+	mv.messagesItem.SetVisible(!anonymous)
+	mv.filterItems[model.PostFilterSubscribed].SetSensitive(!anonymous)
+}
+
 func (mv *MainView) buildAndSetReferences() (builder *gtk.Builder, err error) {
 	builder, err = gtk.BuilderNewFromString(string(data.MainWindowUI))
 	if err != nil {
@@ -131,6 +449,16 @@ func (mv *MainView) buildAndSetReferences() (builder *gtk.Builder, err error) {
 		return
 	}
 
+	mv.searchBox, err = utils.GetUIObject[gtk.Box](builder, "searchBox")
+	if err != nil {
+		return
+	}
+
+	mv.searchScroll, err = utils.GetUIObject[gtk.ScrolledWindow](builder, "searchScroll")
+	if err != nil {
+		return
+	}
+
 	mv.closeComments, err = utils.GetUIObject[gtk.Button](builder, "closeComments")
 	if err != nil {
 		return
@@ -146,6 +474,91 @@ func (mv *MainView) buildAndSetReferences() (builder *gtk.Builder, err error) {
 		return
 	}
 
+	mv.searchPopover, err = utils.GetUIObject[gtk.Popover](builder, "searchPopover")
+	if err != nil {
+		return
+	}
+
+	mv.searchEntry, err = utils.GetUIObject[gtk.SearchEntry](builder, "searchEntry")
+	if err != nil {
+		return
+	}
+
+	mv.newPost, err = utils.GetUIObject[gtk.Button](builder, "newPost")
+	if err != nil {
+		return
+	}
+
+	mv.headerBar, err = utils.GetUIObject[gtk.HeaderBar](builder, "headerBar")
+	if err != nil {
+		return
+	}
+
+	mv.inbox, err = utils.GetUIObject[gtk.Button](builder, "inbox")
+	if err != nil {
+		return
+	}
+
+	mv.inboxImg, err = utils.GetUIObject[gtk.Image](builder, "inboxImg")
+	if err != nil {
+		return
+	}
+
+	mv.inboxCount, err = utils.GetUIObject[gtk.Label](builder, "inboxCount")
+	if err != nil {
+		return
+	}
+
+	mv.logIn, err = utils.GetUIObject[gtk.Button](builder, "logIn")
+	if err != nil {
+		return
+	}
+
+	mv.infoBar, err = utils.GetUIObject[gtk.InfoBar](builder, "infoBar")
+	if err != nil {
+		return
+	}
+
+	mv.infoLabel, err = utils.GetUIObject[gtk.Label](builder, "infoLabel")
+	if err != nil {
+		return
+	}
+
+	mv.infoAction, err = utils.GetUIObject[gtk.Button](builder, "infoAction")
+	if err != nil {
+		return
+	}
+
+	mv.communitiesItem, err = utils.GetUIObject[gtk.MenuItem](builder, "communitiesItem")
+	if err != nil {
+		return
+	}
+
+	mv.accountsMenu, err = utils.GetUIObject[gtk.Menu](builder, "accountsMenu")
+	if err != nil {
+		return
+	}
+
+	mv.savedItem, err = utils.GetUIObject[gtk.MenuItem](builder, "savedItem")
+	if err != nil {
+		return
+	}
+
+	mv.messagesItem, err = utils.GetUIObject[gtk.MenuItem](builder, "messagesItem")
+	if err != nil {
+		return
+	}
+
+	mv.clearCacheItem, err = utils.GetUIObject[gtk.MenuItem](builder, "clearCacheItem")
+	if err != nil {
+		return
+	}
+
+	mv.mainMenu, err = utils.GetUIObject[gtk.Menu](builder, "mainmenu")
+	if err != nil {
+		return
+	}
+
 	mv.orderItems = make(map[int]*gtk.RadioMenuItem)
 	for i := 0; i < 8; i++ {
 		mv.orderItems[i], err = utils.GetUIObject[gtk.RadioMenuItem](builder, "order"+strconv.Itoa(i))
@@ -166,39 +579,877 @@ func (mv *MainView) buildAndSetReferences() (builder *gtk.Builder, err error) {
 }
 
 func (mv *MainView) OpenComments(postID int64) {
-	mv.PostView = &PostView{}
+	mv.PostView = &PostView{Parent: mv, readOnly: mv.Model.Configuration.IsAnonymous()}
+	mv.PostView.CommunityClicked = mv.onCommunityClicked
+	mv.PostView.UserClicked = mv.onUserClicked
+	mv.PostView.VotesChanged = func(postID int64, score int64) {
+		if mv.PostVotesChanged != nil {
+			mv.PostVotesChanged(postID, score)
+		}
+	}
+	mv.PostView.SaveClicked = mv.onPostSaveClicked
+	mv.PostView.MediaNeeded = mv.onPostMediaNeeded
+	mv.PostView.CommentVotesChanged = func(postID int64, commentID int64, score int64) {
+		if mv.CommentVotesChanged != nil {
+			mv.CommentVotesChanged(postID, commentID, score)
+		}
+	}
+	mv.PostView.CommentSaveClicked = func(postID int64, commentID int64, save bool) {
+		if mv.CommentSaveClicked != nil {
+			mv.CommentSaveClicked(postID, commentID, save)
+		}
+	}
+	mv.PostView.CommentSubmitted = func(postID int64, parentID int64, text string) {
+		if mv.CommentSubmitted != nil {
+			mv.CommentSubmitted(postID, parentID, text)
+		}
+	}
+	mv.PostView.CommentEdited = func(postID int64, commentID int64, text string) {
+		if mv.CommentEdited != nil {
+			mv.CommentEdited(postID, commentID, text)
+		}
+	}
+	mv.PostView.CommentDeleted = func(postID int64, commentID int64) {
+		if mv.CommentDeleted != nil {
+			mv.CommentDeleted(postID, commentID)
+		}
+	}
 	err := mv.PostView.SetupPostView(mv.Model.KnownPosts[postID], mv.Model.KnownPosts[postID].Comments, mv.postBox)
 	if err != nil {
 		log.Println(err)
 	}
-	mv.stack.SetTransitionType(gtk.STACK_TRANSITION_TYPE_SLIDE_LEFT)
-	mv.stack.SetVisibleChild(&mv.postScroll.Container)
 
-	mv.closeComments.Show()
-	mv.menu.Hide()
-	mv.search.Hide()
+	mv.pushPage(&mv.postScroll.Container, func() {
+		mv.PostView.Destroy()
+		mv.PostView = nil
+	})
 }
 
-func (mv *MainView) CloseComments() {
-	mv.PostView.Destroy()
-	mv.PostView = nil
-	mv.stack.SetTransitionType(gtk.STACK_TRANSITION_TYPE_SLIDE_RIGHT)
-	mv.stack.SetVisibleChild(&mv.postListScroll.Container)
+func (mv *MainView) OpenSearch(query string) {
+	mv.SearchView.StartSearch(query)
+	if mv.currentPage() != &mv.searchScroll.Container {
+		mv.pushPage(&mv.searchScroll.Container, nil)
+	}
+}
 
-	mv.closeComments.Hide()
-	mv.menu.Show()
-	mv.search.Show()
+func (mv *MainView) SearchFinished() {
+	mv.SearchView.ShowNoResultsIfEmpty()
 }
 
-func (mv *MainView) onNewPosts() {
-	lastAddedPostIDs := mv.Model.ConsumeLastAddedPosts()
-	log.Printf("Adding %d posts to MainWindow...", len(lastAddedPostIDs))
+func (mv *MainView) OpenCommunities() {
+	if mv.CommunityListView != nil {
+		return
+	}
 
-	posts := make([]model.PostModel, 0, len(lastAddedPostIDs))
-	for _, postID := range lastAddedPostIDs {
-		posts = append(posts, mv.Model.KnownPosts[postID])
+	scroll, box, err := mv.newScrollPage()
+	if err != nil {
+		log.Println(err)
+		return
 	}
-	mv.PostListView.FillPostsData(posts)
 
-	log.Println("New posts added to MainWindow.")
+	mv.CommunityListView = &CommunityListView{ReadOnly: mv.Model.Configuration.IsAnonymous()}
+	err = mv.CommunityListView.SetupCommunityListView(box, mv.Model.Communities.Order, mv.Model.Communities.Filter)
+	if err != nil {
+		log.Println(err)
+		scroll.Destroy()
+		mv.CommunityListView = nil
+		return
+	}
+
+	mv.CommunityListView.OrderChanged = func(order int) {
+		if mv.CommunitiesOrderChanged != nil {
+			mv.CommunitiesOrderChanged(order)
+		}
+	}
+	mv.CommunityListView.FilterChanged = func(filter int) {
+		if mv.CommunitiesFilterChanged != nil {
+			mv.CommunitiesFilterChanged(filter)
+		}
+	}
+	mv.CommunityListView.SubscribeClicked = func(communityID int64, follow bool) {
+		if mv.SubscribeClicked != nil {
+			mv.SubscribeClicked(communityID, follow)
+		}
+	}
+	mv.CommunityListView.ShowPostsClicked = func(communityID int64) {
+		if mv.ShowCommunityPostsClicked != nil {
+			mv.ShowCommunityPostsClicked(communityID)
+		}
+	}
+
+	scroll.Connect("edge-reached", func(scroll *gtk.ScrolledWindow, position gtk.PositionType) {
+		if position == gtk.POS_BOTTOM && mv.CommunitiesBottomReached != nil {
+			mv.CommunitiesBottomReached()
+		}
+	})
+
+	mv.pushPage(&scroll.Container, func() {
+		mv.stack.Remove(scroll)
+		mv.CommunityListView = nil
+	})
+}
+
+func (mv *MainView) OpenCommunityPage() {
+	mv.closeDetailPages()
+
+	scroll, box, err := mv.newScrollPage()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	mv.CommunityPageView = &CommunityPageView{}
+	mv.CommunityPageView.ReadOnly = mv.Model.Configuration.IsAnonymous()
+	err = mv.CommunityPageView.SetupCommunityPageView(box)
+	if err != nil {
+		log.Println(err)
+		scroll.Destroy()
+		mv.CommunityPageView = nil
+		return
+	}
+
+	mv.CommunityPageView.CommentClicked = func(postID int64) {
+		if mv.PostListView.CommentClicked != nil {
+			mv.PostListView.CommentClicked(postID)
+		}
+	}
+	mv.CommunityPageView.VotesChanged = func(postID int64, score int64) {
+		if mv.PostVotesChanged != nil {
+			mv.PostVotesChanged(postID, score)
+		}
+	}
+	mv.CommunityPageView.SaveClicked = mv.onPostSaveClicked
+	mv.CommunityPageView.MediaNeeded = mv.onPostMediaNeeded
+	mv.CommunityPageView.UserClicked = mv.onUserClicked
+	mv.CommunityPageView.SubscribeClicked = func(communityID int64, follow bool) {
+		if mv.SubscribeClicked != nil {
+			mv.SubscribeClicked(communityID, follow)
+		}
+	}
+
+	scroll.Connect("edge-reached", func(scroll *gtk.ScrolledWindow, position gtk.PositionType) {
+		if position == gtk.POS_BOTTOM && mv.CommunityPageBottomReached != nil {
+			mv.CommunityPageBottomReached()
+		}
+	})
+
+	mv.pushPage(&scroll.Container, func() {
+		mv.stack.Remove(scroll)
+		mv.CommunityPageView = nil
+	})
+}
+
+func (mv *MainView) OpenProfile() {
+	mv.closeDetailPages()
+
+	scroll, box, err := mv.newScrollPage()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	mv.ProfileView = &ProfileView{}
+	mv.ProfileView.ReadOnly = mv.Model.Configuration.IsAnonymous()
+	err = mv.ProfileView.SetupProfileView(box)
+	if err != nil {
+		log.Println(err)
+		scroll.Destroy()
+		mv.ProfileView = nil
+		return
+	}
+
+	mv.ProfileView.CommentClicked = func(postID int64) {
+		if mv.PostListView.CommentClicked != nil {
+			mv.PostListView.CommentClicked(postID)
+		}
+	}
+	mv.ProfileView.CommunityClicked = mv.onCommunityClicked
+	mv.ProfileView.VotesChanged = func(postID int64, score int64) {
+		if mv.PostVotesChanged != nil {
+			mv.PostVotesChanged(postID, score)
+		}
+	}
+	mv.ProfileView.SaveClicked = mv.onPostSaveClicked
+	mv.ProfileView.MediaNeeded = mv.onPostMediaNeeded
+	mv.ProfileView.MessageClicked = func(personID int64) {
+		if mv.MessageUserClicked != nil {
+			mv.MessageUserClicked(personID)
+		}
+	}
+	mv.ProfileView.CommentContextClicked = func(postID int64) {
+		if mv.CommentContextClicked != nil {
+			mv.CommentContextClicked(postID)
+		}
+	}
+
+	scroll.Connect("edge-reached", func(scroll *gtk.ScrolledWindow, position gtk.PositionType) {
+		if position == gtk.POS_BOTTOM && mv.ProfileBottomReached != nil {
+			mv.ProfileBottomReached()
+		}
+	})
+
+	mv.pushPage(&scroll.Container, func() {
+		mv.stack.Remove(scroll)
+		mv.ProfileView = nil
+	})
+}
+
+func (mv *MainView) OpenSaved() {
+	if mv.SavedView != nil {
+		return
+	}
+
+	scroll, box, err := mv.newScrollPage()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	mv.SavedView = &SavedView{}
+	err = mv.SavedView.SetupSavedView(box)
+	if err != nil {
+		log.Println(err)
+		scroll.Destroy()
+		mv.SavedView = nil
+		return
+	}
+
+	mv.SavedView.CommentClicked = func(postID int64) {
+		if mv.PostListView.CommentClicked != nil {
+			mv.PostListView.CommentClicked(postID)
+		}
+	}
+	mv.SavedView.CommunityClicked = mv.onCommunityClicked
+	mv.SavedView.UserClicked = mv.onUserClicked
+	mv.SavedView.VotesChanged = func(postID int64, score int64) {
+		if mv.PostVotesChanged != nil {
+			mv.PostVotesChanged(postID, score)
+		}
+	}
+	mv.SavedView.SaveClicked = mv.onPostSaveClicked
+	mv.SavedView.MediaNeeded = mv.onPostMediaNeeded
+	mv.SavedView.CommentContextClicked = func(postID int64) {
+		if mv.CommentContextClicked != nil {
+			mv.CommentContextClicked(postID)
+		}
+	}
+
+	scroll.Connect("edge-reached", func(scroll *gtk.ScrolledWindow, position gtk.PositionType) {
+		if position == gtk.POS_BOTTOM && mv.SavedBottomReached != nil {
+			mv.SavedBottomReached()
+		}
+	})
+
+	mv.pushPage(&scroll.Container, func() {
+		mv.stack.Remove(scroll)
+		mv.SavedView = nil
+	})
+}
+
+func (mv *MainView) OpenInbox() {
+	if mv.InboxView != nil {
+		return
+	}
+
+	scroll, box, err := mv.newScrollPage()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	mv.InboxView = &InboxView{}
+	err = mv.InboxView.SetupInboxView(box, mv.Model.Inbox.UnreadOnly)
+	if err != nil {
+		log.Println(err)
+		scroll.Destroy()
+		mv.InboxView = nil
+		return
+	}
+
+	mv.InboxView.UnreadOnlyToggled = func(unreadOnly bool) {
+		if mv.InboxUnreadOnlyToggled != nil {
+			mv.InboxUnreadOnlyToggled(unreadOnly)
+		}
+	}
+	mv.InboxView.MarkAllReadClicked = func() {
+		if mv.InboxMarkAllReadClicked != nil {
+			mv.InboxMarkAllReadClicked()
+		}
+	}
+	mv.InboxView.ItemOpened = func(item model.InboxItem) {
+		if mv.InboxItemOpened != nil {
+			mv.InboxItemOpened(item)
+		}
+	}
+	mv.InboxView.ItemMarkRead = func(item model.InboxItem) {
+		if mv.InboxItemMarkRead != nil {
+			mv.InboxItemMarkRead(item)
+		}
+	}
+
+	scroll.Connect("edge-reached", func(scroll *gtk.ScrolledWindow, position gtk.PositionType) {
+		if position == gtk.POS_BOTTOM && mv.InboxBottomReached != nil {
+			mv.InboxBottomReached()
+		}
+	})
+
+	mv.pushPage(&scroll.Container, func() {
+		mv.stack.Remove(scroll)
+		mv.InboxView = nil
+	})
+}
+
+func (mv *MainView) OpenMessages() {
+	if mv.ConversationListView != nil {
+		return
+	}
+
+	scroll, box, err := mv.newScrollPage()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	mv.ConversationListView = &ConversationListView{}
+	err = mv.ConversationListView.SetupConversationListView(box)
+	if err != nil {
+		log.Println(err)
+		scroll.Destroy()
+		mv.ConversationListView = nil
+		return
+	}
+
+	mv.ConversationListView.ConversationClicked = func(counterpartID int64) {
+		if mv.ConversationClicked != nil {
+			mv.ConversationClicked(counterpartID)
+		}
+	}
+
+	scroll.Connect("edge-reached", func(scroll *gtk.ScrolledWindow, position gtk.PositionType) {
+		if position == gtk.POS_BOTTOM && mv.MessagesBottomReached != nil {
+			mv.MessagesBottomReached()
+		}
+	})
+
+	mv.pushPage(&scroll.Container, func() {
+		mv.stack.Remove(scroll)
+		mv.ConversationListView = nil
+	})
+}
+
+func (mv *MainView) MessagesFinished() {
+	if mv.ConversationListView != nil {
+		mv.ConversationListView.ShowEmptyIfNoConversations()
+	}
+}
+
+func (mv *MainView) OpenConversation(counterpartID int64) {
+	conversation, ok := mv.Model.Messages.Conversations[counterpartID]
+	if !ok {
+		log.Printf("Conversation with %d unknown, can't open it.", counterpartID)
+		return
+	}
+
+	for mv.ConversationView != nil && len(mv.pages) > 1 {
+		mv.GoBack()
+	}
+
+	scroll, box, err := mv.newScrollPage()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	mv.ConversationView = &ConversationView{}
+	err = mv.ConversationView.SetupConversationView(box, *conversation, mv.Model.MyUserID)
+	if err != nil {
+		log.Println(err)
+		scroll.Destroy()
+		mv.ConversationView = nil
+		return
+	}
+
+	mv.ConversationView.MessageSent = func(recipientID int64, text string) {
+		if mv.MessageSent != nil {
+			mv.MessageSent(recipientID, text)
+		}
+	}
+	mv.ConversationView.MessageEdited = func(messageID int64, text string) {
+		if mv.MessageEdited != nil {
+			mv.MessageEdited(messageID, text)
+		}
+	}
+	mv.ConversationView.MessageDeleted = func(messageID int64) {
+		if mv.MessageDeleted != nil {
+			mv.MessageDeleted(messageID)
+		}
+	}
+
+	mv.pushPage(&scroll.Container, func() {
+		mv.stack.Remove(scroll)
+		mv.ConversationView = nil
+	})
+	mv.scrollToBottom(scroll)
+}
+
+func (mv *MainView) SetMessageComposerBusy(messageID int64, busy bool) {
+	if mv.ConversationView != nil {
+		mv.ConversationView.SetComposerBusy(messageID, busy)
+	}
+}
+
+func (mv *MainView) MessageSentSuccessfully() {
+	if mv.ConversationView != nil {
+		mv.ConversationView.ClearComposer()
+	}
+}
+
+func (mv *MainView) InboxFinished() {
+	if mv.InboxView != nil {
+		mv.InboxView.ShowEmptyIfNoItems()
+	}
+}
+
+func (mv *MainView) SetInboxItemRead(kind model.InboxItemKind, id int64) {
+	if mv.InboxView != nil {
+		mv.InboxView.SetItemRead(kind, id)
+	}
+}
+
+func (mv *MainView) SetInboxItemBusy(kind model.InboxItemKind, id int64, busy bool) {
+	if mv.InboxView != nil {
+		mv.InboxView.SetItemBusy(kind, id, busy)
+	}
+}
+
+func (mv *MainView) SetInboxAllRead() {
+	if mv.InboxView != nil {
+		mv.InboxView.SetAllRead()
+	}
+}
+
+func (mv *MainView) ScrollToComment(commentID int64) {
+	if mv.PostView == nil {
+		return
+	}
+
+	commentView, ok := mv.PostView.CommentViews[commentID]
+	if !ok {
+		log.Printf("Comment %d not loaded, can't scroll to it.", commentID)
+		return
+	}
+
+	glib.IdleAdd(func() bool {
+		_, y, err := commentView.CommentBox.TranslateCoordinates(mv.postBox, 0, 0)
+		if err != nil {
+			log.Println(err)
+			return false
+		}
+		mv.postScroll.GetVAdjustment().SetValue(float64(y))
+		return false
+	})
+}
+
+func (mv *MainView) scrollToBottom(scroll *gtk.ScrolledWindow) {
+	glib.IdleAdd(func() bool {
+		adjustment := scroll.GetVAdjustment()
+		adjustment.SetValue(adjustment.GetUpper())
+		return false
+	})
+}
+
+func (mv *MainView) ShowCommunityPageHeader() {
+	if mv.CommunityPageView != nil && mv.Model.CommunityPage.Community.Community.ID != 0 {
+		mv.CommunityPageView.SetCommunity(mv.Model.CommunityPage.Community)
+	}
+}
+
+func (mv *MainView) UpdateCommunity(communityID int64) {
+	community, ok := mv.Model.Communities.KnownCommunities[communityID]
+	if ok && mv.CommunityListView != nil {
+		mv.CommunityListView.UpdateCommunity(community)
+	}
+
+	if mv.CommunityPageView != nil && mv.Model.CommunityPage.Community.Community.ID == communityID {
+		mv.CommunityPageView.SetCommunity(mv.Model.CommunityPage.Community)
+	}
+}
+
+func (mv *MainView) SetCommunityBusy(communityID int64, busy bool) {
+	if mv.CommunityListView != nil {
+		mv.CommunityListView.SetCommunityBusy(communityID, busy)
+	}
+
+	if mv.CommunityPageView != nil && mv.Model.CommunityPage.Community.Community.ID == communityID {
+		mv.CommunityPageView.SetBusy(busy)
+	}
+}
+
+func (mv *MainView) SetFeedSubtitle(subtitle string) {
+	mv.headerBar.SetSubtitle(subtitle)
+}
+
+func (mv *MainView) GoBackToRoot() {
+	for len(mv.pages) > 1 {
+		mv.GoBack()
+	}
+}
+
+func (mv *MainView) GoBack() {
+	if len(mv.pages) <= 1 {
+		return
+	}
+
+	page := mv.pages[len(mv.pages)-1]
+	mv.pages = mv.pages[:len(mv.pages)-1]
+
+	mv.stack.SetTransitionType(gtk.STACK_TRANSITION_TYPE_SLIDE_RIGHT)
+	mv.stack.SetVisibleChild(mv.currentPage())
+	mv.updateHeader()
+
+	if page.onClose != nil {
+		page.onClose()
+	}
+}
+
+func (mv *MainView) closeDetailPages() {
+	for (mv.PostView != nil || mv.CommunityPageView != nil || mv.ProfileView != nil) && len(mv.pages) > 1 {
+		mv.GoBack()
+	}
+}
+
+func (mv *MainView) newScrollPage() (scroll *gtk.ScrolledWindow, box *gtk.Box, err error) {
+	builder, err := gtk.BuilderNewFromString(string(data.PageUI))
+	if err != nil {
+		return
+	}
+
+	scroll, err = utils.GetUIObject[gtk.ScrolledWindow](builder, "pageScroll")
+	if err != nil {
+		return
+	}
+
+	box, err = utils.GetUIObject[gtk.Box](builder, "pageBox")
+	if err != nil {
+		return
+	}
+
+	scroll.Unparent()
+	mv.stack.Add(scroll)
+
+	return
+}
+
+func (mv *MainView) currentPage() *gtk.Container {
+	return mv.pages[len(mv.pages)-1].container
+}
+
+func (mv *MainView) pushPage(container *gtk.Container, onClose func()) {
+	mv.pages = append(mv.pages, mainViewPage{container: container, onClose: onClose})
+
+	mv.stack.SetTransitionType(gtk.STACK_TRANSITION_TYPE_SLIDE_LEFT)
+	mv.stack.SetVisibleChild(container)
+	mv.updateHeader()
+}
+
+func (mv *MainView) updateHeader() {
+	isRoot := len(mv.pages) == 1
+	anonymous := mv.Model.Configuration.IsAnonymous()
+	mv.closeComments.SetVisible(!isRoot)
+	mv.menu.SetVisible(isRoot)
+	mv.search.SetVisible(isRoot)
+	mv.newPost.SetVisible(isRoot && !anonymous)
+	mv.inbox.SetVisible(isRoot && !anonymous)
+}
+
+func (mv *MainView) UpdatePostVotes(postID int64) {
+	post, ok := mv.Model.KnownPosts[postID]
+	if !ok {
+		return
+	}
+
+	mv.PostListView.UpdatePostVotes(post)
+	mv.SearchView.PostListView.UpdatePostVotes(post)
+	if mv.CommunityPageView != nil {
+		mv.CommunityPageView.UpdatePostVotes(post)
+	}
+	if mv.ProfileView != nil {
+		mv.ProfileView.UpdatePostVotes(post)
+	}
+	if mv.SavedView != nil {
+		mv.SavedView.UpdatePostVotes(post)
+	}
+	if mv.PostView != nil && mv.PostView.postID == postID {
+		mv.PostView.UpdateVotes(post)
+	}
+}
+
+func (mv *MainView) UpdatePostMedia(postID int64) {
+	post, ok := mv.Model.KnownPosts[postID]
+	if !ok {
+		return
+	}
+
+	mv.PostListView.UpdatePostMedia(post)
+	mv.SearchView.PostListView.UpdatePostMedia(post)
+	if mv.CommunityPageView != nil {
+		mv.CommunityPageView.UpdatePostMedia(post)
+	}
+	if mv.ProfileView != nil {
+		mv.ProfileView.UpdatePostMedia(post)
+	}
+	if mv.SavedView != nil {
+		mv.SavedView.UpdatePostMedia(post)
+	}
+	if mv.PostView != nil && mv.PostView.postID == postID {
+		mv.PostView.UpdateMedia(post)
+	}
+}
+
+func (mv *MainView) UpdatePostSaved(postID int64) {
+	post, ok := mv.Model.KnownPosts[postID]
+	if !ok {
+		return
+	}
+
+	mv.PostListView.UpdatePostSaved(post)
+	mv.SearchView.PostListView.UpdatePostSaved(post)
+	if mv.CommunityPageView != nil {
+		mv.CommunityPageView.UpdatePostSaved(post)
+	}
+	if mv.ProfileView != nil {
+		mv.ProfileView.UpdatePostSaved(post)
+	}
+	if mv.SavedView != nil {
+		mv.SavedView.UpdatePostSaved(post)
+	}
+	if mv.PostView != nil && mv.PostView.postID == postID {
+		mv.PostView.UpdateSaved(post)
+	}
+}
+
+func (mv *MainView) UpdateCommentSaved(postID int64, commentID int64) {
+	post, ok := mv.Model.KnownPosts[postID]
+	if !ok || mv.PostView == nil || mv.PostView.postID != postID {
+		return
+	}
+
+	if comment := post.FindComment(commentID); comment != nil {
+		mv.PostView.UpdateCommentSaved(*comment)
+	}
+}
+
+func (mv *MainView) UpdateCommentVotes(postID int64, commentID int64) {
+	post, ok := mv.Model.KnownPosts[postID]
+	if !ok || mv.PostView == nil || mv.PostView.postID != postID {
+		return
+	}
+
+	if comment := post.FindComment(commentID); comment != nil {
+		mv.PostView.UpdateCommentVotes(*comment)
+	}
+}
+
+func (mv *MainView) AddComment(postID int64, commentID int64) {
+	post, ok := mv.Model.KnownPosts[postID]
+	if !ok || mv.PostView == nil || mv.PostView.postID != postID {
+		return
+	}
+
+	if comment := post.FindComment(commentID); comment != nil {
+		mv.PostView.AddComment(*comment)
+	}
+}
+
+func (mv *MainView) UpdateComment(postID int64, commentID int64) {
+	post, ok := mv.Model.KnownPosts[postID]
+	if !ok || mv.PostView == nil || mv.PostView.postID != postID {
+		return
+	}
+
+	if comment := post.FindComment(commentID); comment != nil {
+		mv.PostView.UpdateComment(*comment)
+	}
+}
+
+func (mv *MainView) SetComposerBusy(postID int64, commentID int64, busy bool) {
+	if mv.PostView != nil && mv.PostView.postID == postID {
+		mv.PostView.SetComposerBusy(commentID, busy)
+	}
+}
+
+func (mv *MainView) onNewPosts() {
+	lastAddedPostIDs, onTop := mv.Model.ConsumeLastAddedPosts()
+	log.Printf("Adding %d posts to MainWindow...", len(lastAddedPostIDs))
+
+	posts := make([]model.PostModel, 0, len(lastAddedPostIDs))
+	for _, postID := range lastAddedPostIDs {
+		posts = append(posts, mv.Model.KnownPosts[postID])
+	}
+	mv.PostListView.FillPostsData(posts, onTop)
+
+	log.Println("New posts added to MainWindow.")
+}
+
+func (mv *MainView) onNewSearchPosts() {
+	lastAddedPostIDs, _ := mv.Model.Search.ConsumeLastAddedPosts()
+	log.Printf("Adding %d posts to search results...", len(lastAddedPostIDs))
+
+	posts := make([]model.PostModel, 0, len(lastAddedPostIDs))
+	for _, postID := range lastAddedPostIDs {
+		posts = append(posts, mv.Model.KnownPosts[postID])
+	}
+	mv.SearchView.FillPostsData(posts)
+}
+
+func (mv *MainView) onNewSearchResults() {
+	communities, users, comments := mv.Model.Search.ConsumeLastResults()
+	mv.SearchView.FillResults(communities, users, comments)
+}
+
+func (mv *MainView) onNewCommunities() {
+	communities := mv.Model.Communities.ConsumeLastAddedCommunities()
+	if mv.CommunityListView != nil {
+		mv.CommunityListView.FillCommunities(communities)
+	}
+}
+
+func (mv *MainView) onNewCommunityPagePosts() {
+	lastAddedPostIDs, _ := mv.Model.CommunityPage.ConsumeLastAddedPosts()
+	if mv.CommunityPageView == nil {
+		return
+	}
+
+	posts := make([]model.PostModel, 0, len(lastAddedPostIDs))
+	for _, postID := range lastAddedPostIDs {
+		posts = append(posts, mv.Model.KnownPosts[postID])
+	}
+	mv.CommunityPageView.FillPostsData(posts, false)
+}
+
+func (mv *MainView) onCommunityClicked(communityID int64) {
+	if mv.CommunityClicked != nil {
+		mv.CommunityClicked(communityID)
+	}
+}
+
+func (mv *MainView) onNewProfilePosts() {
+	lastAddedPostIDs, _ := mv.Model.Profile.ConsumeLastAddedPosts()
+	if mv.ProfileView == nil {
+		return
+	}
+
+	posts := make([]model.PostModel, 0, len(lastAddedPostIDs))
+	for _, postID := range lastAddedPostIDs {
+		posts = append(posts, mv.Model.KnownPosts[postID])
+	}
+	mv.ProfileView.FillPostsData(posts, false)
+}
+
+func (mv *MainView) onNewProfilePerson() {
+	if mv.ProfileView != nil {
+		mv.ProfileView.SetPerson(mv.Model.Profile.Person, mv.Model.MyUserID)
+	}
+}
+
+func (mv *MainView) onNewProfileComments() {
+	comments := mv.Model.Profile.ConsumeLastAddedComments()
+	if mv.ProfileView != nil {
+		mv.ProfileView.FillComments(comments)
+	}
+}
+
+func (mv *MainView) onNewSavedPosts() {
+	lastAddedPostIDs, _ := mv.Model.Saved.ConsumeLastAddedPosts()
+	if mv.SavedView == nil {
+		return
+	}
+
+	posts := make([]model.PostModel, 0, len(lastAddedPostIDs))
+	for _, postID := range lastAddedPostIDs {
+		posts = append(posts, mv.Model.KnownPosts[postID])
+	}
+	mv.SavedView.FillPostsData(posts, false)
+}
+
+func (mv *MainView) onNewSavedComments() {
+	comments := mv.Model.Saved.ConsumeLastAddedComments()
+	if mv.SavedView != nil {
+		mv.SavedView.FillComments(comments)
+	}
+}
+
+func (mv *MainView) onPostSaveClicked(postID int64, save bool) {
+	if mv.PostSaveClicked != nil {
+		mv.PostSaveClicked(postID, save)
+	}
+}
+
+func (mv *MainView) onPostMediaNeeded(postID int64) {
+	if mv.PostMediaNeeded != nil {
+		mv.PostMediaNeeded(postID)
+	}
+}
+
+func (mv *MainView) onUserClicked(personID int64) {
+	if mv.UserClicked != nil {
+		mv.UserClicked(personID)
+	}
+}
+
+func (mv *MainView) onNewInboxItems() {
+	items := mv.Model.Inbox.ConsumeLastAddedItems()
+	if mv.InboxView != nil {
+		mv.InboxView.FillItems(items)
+	}
+}
+
+func (mv *MainView) onInboxUnreadChanged() {
+	unread := mv.Model.Inbox.UnreadCount()
+	mv.inboxCount.SetText(strconv.FormatInt(unread, 10))
+	mv.inboxCount.SetVisible(unread > 0)
+	if unread > 0 {
+		mv.inboxImg.SetFromIconName("mail-unread-symbolic", gtk.ICON_SIZE_BUTTON)
+	} else {
+		mv.inboxImg.SetFromIconName("mail-read-symbolic", gtk.ICON_SIZE_BUTTON)
+	}
+}
+
+func (mv *MainView) onNewConversations() {
+	if mv.ConversationListView != nil {
+		mv.ConversationListView.FillConversations(mv.Model.Messages.SortedConversations(), mv.Model.MyUserID)
+	}
+
+	if mv.ConversationView != nil {
+		mv.onConversationChanged(mv.ConversationView.CounterpartID)
+	}
+}
+
+func (mv *MainView) onConversationChanged(counterpartID int64) {
+	conversation, ok := mv.Model.Messages.Conversations[counterpartID]
+	if !ok {
+		return
+	}
+
+	if mv.ConversationView != nil && mv.ConversationView.CounterpartID == counterpartID {
+		mv.ConversationView.UpdateConversation(*conversation, mv.Model.MyUserID)
+	}
+	if mv.ConversationListView != nil {
+		mv.ConversationListView.FillConversations(mv.Model.Messages.SortedConversations(), mv.Model.MyUserID)
+	}
+}
+
+func accountLabel(account model.AccountData) string {
+	host := account.Server
+	if serverURL, err := url.Parse(account.Server); err == nil && serverURL.Host != "" {
+		host = serverURL.Host
+	}
+
+	if account.Anonymous {
+		return host + " (no account)"
+	}
+	if account.Username == "" {
+		return host
+	}
+	return account.Username + "@" + host
 }
@@ -0,0 +1,210 @@
+package view
+
+import (
+	"context"
+	"html"
+	"log"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+const inlineImageSize = 290
+
+// MarkdownView lays markdown out in a box: labels for the text and, between
+// them, the images the text embeds and expanders for its spoilers. Clicking an
+// image toggles it between its preview and a bigger size.
+type MarkdownView struct {
+	box      *gtk.Box
+	markdown string
+	expanded utils.ExpandedSpoilers
+}
+
+func NewMarkdownView(box *gtk.Box) *MarkdownView {
+	return &MarkdownView{box: box}
+}
+
+// MarkdownLabel shows markdown in a single label, opening and closing its
+// spoilers when their titles are clicked.
+type MarkdownLabel struct {
+	label    *gtk.Label
+	markdown string
+	expanded utils.ExpandedSpoilers
+}
+
+func NewMarkdownLabel(label *gtk.Label) *MarkdownLabel {
+	ml := &MarkdownLabel{label: label}
+	label.Connect("activate-link", func(label *gtk.Label, uri string) bool {
+		index, ok := utils.SpoilerIndex(uri)
+		if !ok {
+			return false
+		}
+		ml.expanded[index] = !ml.expanded[index]
+		ml.label.SetMarkup(utils.MarkdownToLabelMarkup(ml.markdown, ml.expanded))
+		return true
+	})
+	return ml
+}
+
+func (ml *MarkdownLabel) SetMarkdown(markdown string) {
+	ml.markdown = markdown
+	ml.expanded = make(utils.ExpandedSpoilers)
+	ml.label.SetMarkup(utils.MarkdownToLabelMarkup(markdown, ml.expanded))
+}
+
+func (mv *MarkdownView) SetMarkdown(markdown string) {
+	mv.markdown = markdown
+	mv.expanded = make(utils.ExpandedSpoilers)
+	mv.render()
+}
+
+// SetMarkup shows markup as it is, for things like "deleted" placeholders.
+func (mv *MarkdownView) SetMarkup(markup string) {
+	removeAllChildren(&mv.box.Container)
+	mv.addText(mv.box, markup)
+}
+
+func (mv *MarkdownView) render() {
+	removeAllChildren(&mv.box.Container)
+	mv.addBlocks(mv.box, utils.MarkdownToBlocks(mv.markdown, mv.expanded))
+}
+
+func (mv *MarkdownView) addBlocks(box *gtk.Box, blocks []utils.MarkdownBlock) {
+	for _, block := range blocks {
+		if block.SpoilerTitle != "" {
+			mv.addSpoiler(box, block)
+		} else if block.ImageURL != "" {
+			mv.addImage(box, block.ImageURL, block.ImageAlt)
+		} else {
+			mv.addText(box, block.Markup)
+		}
+	}
+}
+
+func (mv *MarkdownView) addText(box *gtk.Box, markup string) {
+	label, err := newMarkdownLabel(markup)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	// Spoilers nested in lists or quotes are links in the text, and opening
+	// them means laying everything out again.
+	label.Connect("activate-link", func(label *gtk.Label, uri string) bool {
+		index, ok := utils.SpoilerIndex(uri)
+		if !ok {
+			return false
+		}
+		mv.expanded[index] = !mv.expanded[index]
+		mv.render()
+		return true
+	})
+	box.PackStart(label, false, true, 0)
+}
+
+func (mv *MarkdownView) addSpoiler(box *gtk.Box, spoiler utils.MarkdownBlock) {
+	expander, err := gtk.ExpanderNew(spoiler.SpoilerTitle)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	content, err := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, box.GetSpacing())
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	mv.addBlocks(content, spoiler.Spoiler)
+	expander.SetExpanded(mv.expanded[spoiler.SpoilerIndex])
+	expander.Connect("notify::expanded", func() {
+		mv.expanded[spoiler.SpoilerIndex] = expander.GetExpanded()
+	})
+	expander.Add(content)
+	expander.ShowAll()
+	box.PackStart(expander, false, true, 0)
+}
+
+func (mv *MarkdownView) addImage(box *gtk.Box, url string, alt string) {
+	events, err := gtk.EventBoxNew()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	image, err := gtk.ImageNew()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if alt != "" {
+		events.SetTooltipText(alt)
+	}
+	image.SetHAlign(gtk.ALIGN_START)
+	events.Add(image)
+	events.Show()
+	box.PackStart(events, false, true, 0)
+
+	var pixbuf *gdk.Pixbuf
+	expanded := false
+	events.Connect("button-press-event", func() {
+		if pixbuf == nil {
+			return
+		}
+		expanded = !expanded
+		utils.SetDirectImage(image, pixbuf, inlineImageLimits(expanded), nil)
+	})
+
+	var taskSequence *utils.TaskSequence[*gdk.Pixbuf]
+	taskSequence = utils.NewTaskSequence[*gdk.Pixbuf](func() {
+		taskSequence = nil
+	})
+	taskSequence.Add(func() (*gdk.Pixbuf, error) {
+		return utils.LoadPixmapFromUrl(context.Background(), url)
+	}, func(loaded *gdk.Pixbuf, err error) bool {
+		if err != nil {
+			log.Printf("Couldn't load inline image '%s': %s", url, err)
+			showImageLink(events, image, url, alt)
+			return true
+		}
+		pixbuf = loaded
+		utils.SetDirectImage(image, pixbuf, inlineImageLimits(expanded), nil)
+		return true
+	})
+	taskSequence.Execute()
+}
+
+// showImageLink replaces an image that couldn't be loaded with a link to it.
+func showImageLink(events *gtk.EventBox, image *gtk.Image, url string, alt string) {
+	if alt == "" {
+		alt = url
+	}
+	label, err := newMarkdownLabel(`<a href="` + html.EscapeString(url) + `">` + html.EscapeString(alt) + `</a>`)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	events.Remove(image)
+	events.Add(label)
+}
+
+func newMarkdownLabel(markup string) (*gtk.Label, error) {
+	label, err := gtk.LabelNew("")
+	if err != nil {
+		return nil, err
+	}
+
+	label.SetMarkup(markup)
+	label.SetLineWrap(true)
+	label.SetSelectable(true)
+	label.SetMaxWidthChars(1)
+	label.SetXAlign(0)
+	label.Show()
+	return label, nil
+}
+
+func inlineImageLimits(expanded bool) [2]int {
+	if expanded {
+		return [2]int{maxPostImageSize, maxPostImageSize * 2}
+	}
+	return [2]int{maxPostImageSize, inlineImageSize}
+}
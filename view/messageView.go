@@ -0,0 +1,160 @@
+package view
+
+import (
+	"log"
+	"time"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+type MessageView struct {
+	MessageBox    *gtk.Box
+	EditSubmitted func(int64, string)
+	DeleteClicked func(int64)
+
+	messageID   int64
+	content     string
+	mine        bool
+	composer    *CommentComposerView
+	card        *gtk.Box
+	author      *gtk.Label
+	time        *gtk.Label
+	contentText *gtk.Label
+	markdown    *MarkdownLabel
+	composerBox *gtk.Box
+	actions     *gtk.Box
+}
+
+func NewMessageView(message model.PrivateMessageModel, mine bool) (mv *MessageView, err error) {
+	mv = &MessageView{mine: mine}
+	_, err = mv.buildAndSetReferences()
+	if err != nil {
+		return
+	}
+
+	mv.messageID = message.PrivateMessage.ID
+	mv.author.SetText(message.Creator.DisplayName.ValueOr(message.Creator.Name))
+	if mine {
+		mv.card.SetHAlign(gtk.ALIGN_END)
+	} else {
+		mv.card.SetHAlign(gtk.ALIGN_START)
+	}
+	mv.UpdateMessage(message)
+
+	return
+}
+
+func (mv *MessageView) UpdateMessage(message model.PrivateMessageModel) {
+	mv.CloseComposer()
+	mv.content = message.PrivateMessage.Content
+	mv.time.SetText(utils.GetNiceDuration(time.Since(message.PrivateMessage.Published)))
+
+	if message.PrivateMessage.Deleted {
+		mv.contentText.SetMarkup("<i>deleted</i>")
+	} else {
+		mv.markdown.SetMarkdown(message.PrivateMessage.Content)
+	}
+	mv.actions.SetVisible(mv.mine && !message.PrivateMessage.Deleted)
+}
+
+func (mv *MessageView) SetComposerBusy(busy bool) {
+	if mv.composer != nil {
+		mv.composer.SetBusy(busy)
+	}
+}
+
+func (mv *MessageView) CloseComposer() {
+	if mv.composer == nil {
+		return
+	}
+
+	mv.composer.Destroy()
+	mv.composer = nil
+	mv.contentText.Show()
+}
+
+func (mv *MessageView) buildAndSetReferences() (builder *gtk.Builder, err error) {
+	builder, err = gtk.BuilderNewFromString(string(data.MessageUI))
+	if err != nil {
+		return
+	}
+
+	mv.card, err = utils.GetUIObject[gtk.Box](builder, "card")
+	if err != nil {
+		return
+	}
+	utils.ApplyStyle(&mv.card.Widget)
+
+	mv.author, err = utils.GetUIObject[gtk.Label](builder, "author")
+	if err != nil {
+		return
+	}
+
+	mv.time, err = utils.GetUIObject[gtk.Label](builder, "time")
+	if err != nil {
+		return
+	}
+
+	mv.contentText, err = utils.GetUIObject[gtk.Label](builder, "content")
+	if err != nil {
+		return
+	}
+	mv.markdown = NewMarkdownLabel(mv.contentText)
+
+	mv.composerBox, err = utils.GetUIObject[gtk.Box](builder, "composerParent")
+	if err != nil {
+		return
+	}
+
+	mv.actions, err = utils.GetUIObject[gtk.Box](builder, "actions")
+	if err != nil {
+		return
+	}
+
+	editButton, err := utils.GetUIObject[gtk.Button](builder, "edit")
+	if err != nil {
+		return
+	}
+	editButton.Connect("clicked", mv.openComposer)
+
+	deleteButton, err := utils.GetUIObject[gtk.Button](builder, "delete")
+	if err != nil {
+		return
+	}
+	deleteButton.Connect("clicked", func() {
+		if mv.DeleteClicked != nil && utils.Confirm(&mv.MessageBox.Widget, "Delete this message?") {
+			mv.DeleteClicked(mv.messageID)
+		}
+	})
+
+	mv.MessageBox, err = utils.GetUIObject[gtk.Box](builder, "message")
+	if err != nil {
+		return
+	}
+	mv.MessageBox.Unparent()
+
+	return
+}
+
+func (mv *MessageView) openComposer() {
+	mv.CloseComposer()
+
+	var err error
+	mv.composer, err = NewCommentComposerView(mv.content, true)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	mv.composer.Submitted = func(text string) {
+		if mv.EditSubmitted != nil {
+			mv.EditSubmitted(mv.messageID, text)
+		}
+	}
+	mv.composer.Cancelled = mv.CloseComposer
+	mv.composerBox.PackStart(mv.composer.ComposerBox, true, true, 0)
+	mv.contentText.Hide()
+}
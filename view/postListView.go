@@ -9,10 +9,17 @@ import (
 )
 
 type PostListView struct {
-	CommentClicked func(int64)
+	CommentClicked   func(int64)
+	CommunityClicked func(int64)
+	UserClicked      func(int64)
+	VotesChanged     func(int64, int64)
+	SaveClicked      func(int64, bool)
+	MediaNeeded      func(int64)
+	// ReadOnly hides the actions that need an account on the posts added from now on.
+	ReadOnly bool
 
 	postsBox   *gtk.Box
-	postViews  []PostView
+	postViews  []*PostView
 	shownPosts []int64
 }
 
@@ -23,8 +30,12 @@ func (plv *PostListView) SetupPostListView(box *gtk.Box) (err error) {
 }
 
 func (plv *PostListView) CleanView() {
+	// Destroying the post views also stops the videos they were playing.
+	for _, postView := range plv.postViews {
+		postView.Destroy()
+	}
 	plv.shownPosts = make([]int64, 0)
-	plv.postViews = make([]PostView, 0)
+	plv.postViews = make([]*PostView, 0)
 	plv.postsBox.GetChildren().Foreach(func(child interface{}) {
 		widget, ok := child.(gtk.IWidget)
 		if ok {
@@ -33,7 +44,7 @@ func (plv *PostListView) CleanView() {
 	})
 }
 
-func (plv *PostListView) FillPostsData(posts []model.PostModel) {
+func (plv *PostListView) FillPostsData(posts []model.PostModel, onTop bool) {
 	for _, post := range posts {
 		if slices.Index(plv.shownPosts, post.Post.ID) != -1 {
 			log.Printf("Post %d already being shown, skipping.", post.Post.ID)
@@ -41,19 +52,100 @@ func (plv *PostListView) FillPostsData(posts []model.PostModel) {
 		}
 
 		log.Printf("Adding post %d to PostsUI...", post.Post.ID)
-		plv.shownPosts = append(plv.shownPosts, post.Post.ID)
-		plv.postViews = append(plv.postViews, PostView{})
-		postView := plv.postViews[len(plv.postViews)-1]
+		postView := &PostView{readOnly: plv.ReadOnly}
+		// The slices follow the order on screen, which the image viewer goes through.
+		if onTop {
+			plv.shownPosts = slices.Insert(plv.shownPosts, 0, post.Post.ID)
+			plv.postViews = slices.Insert(plv.postViews, 0, postView)
+		} else {
+			plv.shownPosts = append(plv.shownPosts, post.Post.ID)
+			plv.postViews = append(plv.postViews, postView)
+		}
 		err := postView.SetupPostView(post, nil, plv.postsBox)
 		if err != nil {
 			log.Println(err)
 		}
+		if onTop {
+			plv.postsBox.ReorderChild(postView.post, 0)
+		}
 
 		postView.CommentsButtonClicked = func(id int64) {
 			if plv.CommentClicked != nil {
 				plv.CommentClicked(id)
 			}
 		}
+		postView.CommunityClicked = func(id int64) {
+			if plv.CommunityClicked != nil {
+				plv.CommunityClicked(id)
+			}
+		}
+		postView.UserClicked = func(id int64) {
+			if plv.UserClicked != nil {
+				plv.UserClicked(id)
+			}
+		}
+		postView.VotesChanged = func(id int64, score int64) {
+			if plv.VotesChanged != nil {
+				plv.VotesChanged(id, score)
+			}
+		}
+		postView.SaveClicked = func(id int64, save bool) {
+			if plv.SaveClicked != nil {
+				plv.SaveClicked(id, save)
+			}
+		}
+		postView.MediaNeeded = func(id int64) {
+			if plv.MediaNeeded != nil {
+				plv.MediaNeeded(id)
+			}
+		}
+		postView.ImageClicked = plv.openImageViewer
 		log.Printf("Added post %d to PostUI.", post.Post.ID)
 	}
 }
+
+// openImageViewer opens the viewer on the image of postID, letting it go
+// through the images of the rest of the posts in the list.
+func (plv *PostListView) openImageViewer(postID int64) {
+	images := make([]ViewerImage, 0)
+	index := 0
+	for i, postView := range plv.postViews {
+		image, ok := postView.viewerImage()
+		if !ok {
+			continue
+		}
+		if plv.shownPosts[i] == postID {
+			index = len(images)
+		}
+		images = append(images, image)
+	}
+	if len(images) == 0 {
+		return
+	}
+
+	openImageViewer(&plv.postsBox.Widget, images, index)
+}
+
+func (plv *PostListView) UpdatePostVotes(post model.PostModel) {
+	index := slices.Index(plv.shownPosts, post.Post.ID)
+	if index == -1 {
+		return
+	}
+	plv.postViews[index].UpdateVotes(post)
+}
+
+func (plv *PostListView) UpdatePostMedia(post model.PostModel) {
+	index := slices.Index(plv.shownPosts, post.Post.ID)
+	if index == -1 {
+		return
+	}
+	plv.postViews[index].UpdateMedia(post)
+}
+
+func (plv *PostListView) UpdatePostSaved(post model.PostModel) {
+	index := slices.Index(plv.shownPosts, post.Post.ID)
+	if index == -1 {
+		return
+	}
+	plv.postViews[index].UpdateSaved(post)
+}
@@ -5,19 +5,46 @@ import (
 	"log"
 	"time"
 
+	"github.com/gotk3/gotk3/glib"
 	"github.com/gotk3/gotk3/gtk"
 	"github.com/mjdiliscia/LemmeRead/data"
 	"github.com/mjdiliscia/LemmeRead/model"
 	"github.com/mjdiliscia/LemmeRead/utils"
 )
 
-const MAX_BRIEF_DESC_LEN int = 500
+const (
+	MAX_BRIEF_DESC_LEN   int = 500
+	mediaPlaceholderSize     = 64
+)
 
 type PostView struct {
 	Parent                *MainView
-	CommentViews          map[int64]CommentView
+	CommentViews          map[int64]*CommentView
 	CommentsButtonClicked func(int64)
-
+	CommunityClicked      func(int64)
+	UserClicked           func(int64)
+	VotesChanged          func(int64, int64)
+	SaveClicked           func(int64, bool)
+	// ImageClicked is called when the image of an image post is clicked. If
+	// it's not set, the viewer is opened with just that image.
+	ImageClicked func(int64)
+	// MediaNeeded is called once the post is seen if its media isn't loaded.
+	MediaNeeded         func(int64)
+	CommentVotesChanged func(int64, int64, int64)
+	CommentSaveClicked  func(int64, int64, bool)
+	CommentSubmitted    func(int64, int64, string)
+	CommentEdited       func(int64, int64, string)
+	CommentDeleted      func(int64, int64)
+
+	postID         int64
+	readOnly       bool
+	communityID    int64
+	creatorID      int64
+	baseScore      int64
+	updatingVotes  bool
+	saved          bool
+	composer       *CommentComposerView
+	composerBox    *gtk.Box
 	parentBox      *gtk.Box
 	post           *gtk.Box
 	title          *gtk.Label
@@ -27,8 +54,14 @@ type PostView struct {
 	timestamp      *gtk.Label
 	link           *gtk.LinkButton
 	image          *gtk.Image
-	description    *gtk.Label
+	imageURL       string
+	videoParent    *gtk.Box
+	video          *VideoView
+	previewParent  *gtk.Box
+	description    *gtk.Box
+	body           *MarkdownView
 	votes          *gtk.SpinButton
+	saveButton     *gtk.Button
 	commentsBox    *gtk.Box
 	commentsButton *gtk.Button
 }
@@ -42,6 +75,11 @@ func (pv *PostView) SetupPostView(post model.PostModel, comments []*model.Commen
 
 	pv.fillPostData(post, comments == nil)
 	pv.buildComments(comments)
+	if pv.readOnly {
+		pv.votes.SetSensitive(false)
+		pv.saveButton.Hide()
+		pv.composerBox.Hide()
+	}
 
 	pv.parentBox.PackStart(pv.post, false, false, 0)
 
@@ -49,6 +87,9 @@ func (pv *PostView) SetupPostView(post model.PostModel, comments []*model.Commen
 }
 
 func (pv *PostView) Destroy() {
+	if pv.video != nil {
+		pv.video.Destroy()
+	}
 	pv.parentBox.Remove(pv.post)
 }
 
@@ -84,11 +125,34 @@ func (pv *PostView) buildAndSetReferences() (builder *gtk.Builder, err error) {
 	}
 	utils.ApplyStyle(&pv.communityName.Widget)
 
+	for _, eventBoxName := range []string{"communityIconEvents", "communityNameEvents"} {
+		var eventBox *gtk.EventBox
+		eventBox, err = utils.GetUIObject[gtk.EventBox](builder, eventBoxName)
+		if err != nil {
+			return
+		}
+		eventBox.Connect("button-press-event", func() {
+			if pv.CommunityClicked != nil {
+				pv.CommunityClicked(pv.communityID)
+			}
+		})
+	}
+
 	pv.username, err = utils.GetUIObject[gtk.Label](builder, "username")
 	if err != nil {
 		return
 	}
 
+	usernameEvents, err := utils.GetUIObject[gtk.EventBox](builder, "usernameEvents")
+	if err != nil {
+		return
+	}
+	usernameEvents.Connect("button-press-event", func() {
+		if pv.UserClicked != nil {
+			pv.UserClicked(pv.creatorID)
+		}
+	})
+
 	pv.link, err = utils.GetUIObject[gtk.LinkButton](builder, "linkButton")
 	if err != nil {
 		return
@@ -104,16 +168,63 @@ func (pv *PostView) buildAndSetReferences() (builder *gtk.Builder, err error) {
 		return
 	}
 
-	pv.description, err = utils.GetUIObject[gtk.Label](builder, "description")
+	imageEvents, err := utils.GetUIObject[gtk.EventBox](builder, "imageEvents")
+	if err != nil {
+		return
+	}
+	imageEvents.Connect("button-press-event", func() {
+		image, ok := pv.viewerImage()
+		if !ok {
+			return
+		}
+		if pv.ImageClicked != nil {
+			pv.ImageClicked(pv.postID)
+		} else {
+			openImageViewer(&pv.post.Widget, []ViewerImage{image}, 0)
+		}
+	})
+
+	pv.videoParent, err = utils.GetUIObject[gtk.Box](builder, "videoParent")
+	if err != nil {
+		return
+	}
+
+	pv.previewParent, err = utils.GetUIObject[gtk.Box](builder, "linkPreviewParent")
 	if err != nil {
 		return
 	}
 
+	pv.description, err = utils.GetUIObject[gtk.Box](builder, "description")
+	if err != nil {
+		return
+	}
+	pv.body = NewMarkdownView(pv.description)
+
 	pv.votes, err = utils.GetUIObject[gtk.SpinButton](builder, "votes")
 	if err != nil {
 		return
 	}
 	pv.votes.SetIncrements(1, 1)
+	pv.votes.Connect("value-changed", func() {
+		if !pv.updatingVotes && pv.VotesChanged != nil {
+			pv.VotesChanged(pv.postID, int64(pv.votes.GetValue())-pv.baseScore)
+		}
+	})
+
+	pv.saveButton, err = utils.GetUIObject[gtk.Button](builder, "save")
+	if err != nil {
+		return
+	}
+	pv.saveButton.Connect("clicked", func() {
+		if pv.SaveClicked != nil {
+			pv.SaveClicked(pv.postID, !pv.saved)
+		}
+	})
+
+	pv.composerBox, err = utils.GetUIObject[gtk.Box](builder, "composerParent")
+	if err != nil {
+		return
+	}
 
 	pv.commentsBox, err = utils.GetUIObject[gtk.Box](builder, "commentsParent")
 	if err != nil {
@@ -130,7 +241,68 @@ func (pv *PostView) buildAndSetReferences() (builder *gtk.Builder, err error) {
 	return
 }
 
+func (pv *PostView) UpdateVotes(post model.PostModel) {
+	pv.updatingVotes = true
+	pv.baseScore = setVotesRange(pv.votes, post.Counts.Score, post.MyVote.ValueOrZero())
+	pv.updatingVotes = false
+}
+
+func (pv *PostView) UpdateSaved(post model.PostModel) {
+	pv.saved = post.Saved
+	pv.saveButton.SetLabel(saveLabel(post.Saved))
+}
+
+func (pv *PostView) UpdateCommentVotes(comment model.CommentModel) {
+	if commentView, ok := pv.CommentViews[comment.Comment.ID]; ok {
+		commentView.UpdateVotes(comment)
+	}
+}
+
+func (pv *PostView) UpdateCommentSaved(comment model.CommentModel) {
+	if commentView, ok := pv.CommentViews[comment.Comment.ID]; ok {
+		commentView.UpdateSaved(comment)
+	}
+}
+
+func (pv *PostView) AddComment(comment model.CommentModel) {
+	commentView := pv.newCommentView(comment)
+	if commentView == nil {
+		return
+	}
+
+	if parentView, ok := pv.CommentViews[comment.ParentID()]; ok {
+		parentView.AddChildComment(commentView)
+		parentView.CloseComposer()
+	} else {
+		// New comments go on top so the author can see them without scrolling.
+		pv.commentsBox.PackStart(commentView.CommentBox, true, false, 5)
+		pv.commentsBox.ReorderChild(commentView.CommentBox, 0)
+		if pv.composer != nil {
+			pv.composer.Clear()
+			pv.composer.SetBusy(false)
+		}
+	}
+}
+
+func (pv *PostView) UpdateComment(comment model.CommentModel) {
+	if commentView, ok := pv.CommentViews[comment.Comment.ID]; ok {
+		commentView.UpdateComment(comment)
+		commentView.CloseComposer()
+	}
+}
+
+func (pv *PostView) SetComposerBusy(commentID int64, busy bool) {
+	if commentView, ok := pv.CommentViews[commentID]; ok {
+		commentView.SetComposerBusy(busy)
+	} else if commentID == 0 && pv.composer != nil {
+		pv.composer.SetBusy(busy)
+	}
+}
+
 func (pv *PostView) fillPostData(post model.PostModel, briefDesc bool) {
+	pv.postID = post.Post.ID
+	pv.communityID = post.Community.ID
+	pv.creatorID = post.Creator.ID
 	pv.title.SetText(post.Post.Name)
 
 	if post.Post.Body.IsValid() {
@@ -138,7 +310,7 @@ func (pv *PostView) fillPostData(post model.PostModel, briefDesc bool) {
 		if briefDesc && len(body) > MAX_BRIEF_DESC_LEN {
 			body = body[:MAX_BRIEF_DESC_LEN] + "..."
 		}
-		pv.description.SetMarkup(utils.MarkdownToLabelMarkup(body))
+		pv.body.SetMarkdown(body)
 	} else {
 		pv.description.Hide()
 	}
@@ -147,8 +319,8 @@ func (pv *PostView) fillPostData(post model.PostModel, briefDesc bool) {
 	pv.username.SetText(post.Creator.DisplayName.ValueOr(post.Creator.Name))
 	pv.timestamp.SetText(utils.GetNiceDuration(time.Since(post.Post.Published)))
 
-	pv.votes.SetRange(float64(post.Counts.Score)-1, float64(post.Counts.Score)+1)
-	pv.votes.SetValue(float64(post.Counts.Score))
+	pv.UpdateVotes(post)
+	pv.UpdateSaved(post)
 
 	if briefDesc {
 		pv.commentsButton.SetLabel(fmt.Sprintf("%d comments", post.Counts.Comments))
@@ -158,40 +330,206 @@ func (pv *PostView) fillPostData(post model.PostModel, briefDesc bool) {
 			}
 		})
 		pv.commentsBox.Hide()
+		pv.composerBox.Hide()
 	} else {
 		pv.commentsButton.Hide()
 	}
 
-	if !post.IsImagePost && post.Link != "" {
-		pv.link.SetUri(post.Link)
-		pv.link.Show()
+	if post.MediaLoaded {
+		pv.UpdateMedia(post)
+	} else {
+		pv.setLink(post)
+		pv.showMediaPlaceholder(post)
+		pv.requestMediaWhenSeen()
+	}
+}
+
+// UpdateMedia replaces the placeholders of the post with its loaded media.
+func (pv *PostView) UpdateMedia(post model.PostModel) {
+	pv.image.Clear()
+	pv.image.Hide()
+	pv.setMedia(post)
+	pv.setLink(post)
+
+	if post.CommunityIcon != nil {
+		utils.SetDirectImage(pv.communityIcon, post.CommunityIcon, [2]int{communityIconSize, communityIconSize}, nil)
+	}
+}
+
+// requestMediaWhenSeen asks for the media the first time the post is drawn.
+// Widgets scrolled out of view aren't drawn, so that's when it's seen.
+func (pv *PostView) requestMediaWhenSeen() {
+	var handle glib.SignalHandle
+	handle = pv.post.Connect("draw", func() bool {
+		pv.post.HandlerDisconnect(handle)
+		if pv.MediaNeeded != nil {
+			pv.MediaNeeded(pv.postID)
+		}
+		return false
+	})
+}
+
+// showMediaPlaceholder keeps a place for media that may be coming.
+func (pv *PostView) showMediaPlaceholder(post model.PostModel) {
+	if post.Media == model.MediaNone || (post.Media == model.MediaLink && !post.Post.ThumbnailURL.IsValid()) {
+		return
+	}
+	pv.image.SetFromIconName("image-loading-symbolic", gtk.ICON_SIZE_DIALOG)
+	pv.image.SetPixelSize(mediaPlaceholderSize)
+	pv.image.Show()
+}
+
+// viewerImage is the original of the image of the post, for image posts.
+func (pv *PostView) viewerImage() (ViewerImage, bool) {
+	if pv.imageURL == "" {
+		return ViewerImage{}, false
+	}
+	return ViewerImage{URL: pv.imageURL, Title: pv.title.GetLabel()}, true
+}
+
+func (pv *PostView) setMedia(post model.PostModel) {
+	if post.Media == model.MediaImage && post.Image != nil {
+		pv.imageURL = post.Post.URL.ValueOrZero()
+		pv.image.SetTooltipText("Click to see the original")
+	}
+
+	switch {
+	case post.Media == model.MediaVideo:
+		var err error
+		pv.video, err = NewVideoView(post.Post.URL.ValueOrZero(), post.Image)
+		if err != nil {
+			log.Println(err)
+			break
+		}
+		pv.videoParent.PackStart(pv.video.Video, false, true, 0)
+		return
+	case post.Animation != nil && post.Image != nil && post.Image.GetWidth() <= maxPostImageSize:
+		// Animations can't be scaled, so only those fitting in the post are played.
+		pv.image.SetFromAnimation(post.Animation)
+		pv.image.Show()
+		return
 	}
 
 	if post.Image != nil {
 		utils.SetDirectImage(pv.image, post.Image, [2]int{maxPostImageSize, maxPostImageSize}, nil)
 	}
+}
+
+// setLink shows a card describing the linked page when there's something to
+// say about it, or just the link otherwise.
+func (pv *PostView) setLink(post model.PostModel) {
+	if post.Link != "" && post.Preview != nil {
+		preview, err := NewLinkPreviewView(*post.Preview, post.PreviewIcon)
+		if err == nil {
+			pv.previewParent.PackStart(preview.LinkPreview, false, true, 0)
+			pv.link.Hide()
+			return
+		}
+		log.Println(err)
+	}
 
-	if post.CommunityIcon != nil {
-		utils.SetDirectImage(pv.communityIcon, post.CommunityIcon, [2]int{communityIconSize, communityIconSize}, nil)
+	if post.Link != "" {
+		pv.link.SetUri(post.Link)
+		pv.link.Show()
+	} else {
+		pv.link.Hide()
 	}
 }
 
 func (pv *PostView) buildComments(inComments []*model.CommentModel) {
-	addCommentsTo(pv.commentsBox, inComments)
+	pv.CommentViews = make(map[int64]*CommentView)
+	if inComments == nil {
+		return
+	}
+
+	var err error
+	pv.composer, err = NewCommentComposerView("", false)
+	if err != nil {
+		log.Println(err)
+	} else {
+		pv.composer.Submitted = func(text string) {
+			if pv.CommentSubmitted != nil {
+				pv.CommentSubmitted(pv.postID, 0, text)
+			}
+		}
+		pv.composerBox.PackStart(pv.composer.ComposerBox, true, true, 0)
+	}
+
+	pv.addCommentsTo(pv.commentsBox, inComments)
 }
 
-func addCommentsTo(box *gtk.Box, comments []*model.CommentModel) {
+func (pv *PostView) addCommentsTo(box *gtk.Box, comments []*model.CommentModel) {
 	for _, comment := range comments {
 		log.Printf("Adding comment %d", comment.Comment.ID)
-		commentView, err := NewCommentView(*comment)
-		if err != nil {
-			log.Printf("Error creating comment UI for %d", comment.Comment.ID)
+		commentView := pv.newCommentView(*comment)
+		if commentView == nil {
 			return
 		}
+
 		box.PackStart(commentView.CommentBox, true, false, 5)
 		if len(comment.ChildComments) > 0 {
 			log.Printf("%d has %d children", comment.Comment.ID, len(comment.ChildComments))
-			addCommentsTo(commentView.childCommentsBox, comment.ChildComments)
+			pv.addCommentsTo(commentView.childCommentsBox, comment.ChildComments)
+		}
+	}
+}
+
+func (pv *PostView) newCommentView(comment model.CommentModel) *CommentView {
+	editable := pv.Parent != nil && pv.Parent.Model.MyUserID != 0 && comment.Creator.ID == pv.Parent.Model.MyUserID
+	commentView, err := NewCommentView(comment, editable)
+	if err != nil {
+		log.Printf("Error creating comment UI for %d", comment.Comment.ID)
+		return nil
+	}
+	if pv.readOnly {
+		commentView.SetReadOnly()
+	}
+
+	commentView.VotesChanged = func(commentID int64, score int64) {
+		if pv.CommentVotesChanged != nil {
+			pv.CommentVotesChanged(pv.postID, commentID, score)
+		}
+	}
+	commentView.SaveClicked = func(commentID int64, save bool) {
+		if pv.CommentSaveClicked != nil {
+			pv.CommentSaveClicked(pv.postID, commentID, save)
+		}
+	}
+	commentView.ReplySubmitted = func(commentID int64, text string) {
+		if pv.CommentSubmitted != nil {
+			pv.CommentSubmitted(pv.postID, commentID, text)
+		}
+	}
+	commentView.EditSubmitted = func(commentID int64, text string) {
+		if pv.CommentEdited != nil {
+			pv.CommentEdited(pv.postID, commentID, text)
+		}
+	}
+	commentView.DeleteClicked = func(commentID int64) {
+		if pv.CommentDeleted != nil {
+			pv.CommentDeleted(pv.postID, commentID)
 		}
 	}
+	commentView.UserClicked = func(personID int64) {
+		if pv.UserClicked != nil {
+			pv.UserClicked(personID)
+		}
+	}
+	pv.CommentViews[comment.Comment.ID] = commentView
+
+	return commentView
+}
+
+func setVotesRange(votes *gtk.SpinButton, score int64, myVote int64) (baseScore int64) {
+	baseScore = score - myVote
+	votes.SetRange(float64(baseScore)-1, float64(baseScore)+1)
+	votes.SetValue(float64(score))
+	return
+}
+
+func saveLabel(saved bool) string {
+	if saved {
+		return "Unsave"
+	}
+	return "Save"
 }
@@ -0,0 +1,148 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+const profileAvatarSize = 64
+
+type ProfileView struct {
+	PostListView
+	CommentContextClicked func(int64)
+	MessageClicked        func(int64)
+
+	profileBox  *gtk.Box
+	avatar      *gtk.Image
+	displayName *gtk.Label
+	username    *gtk.Label
+	joined      *gtk.Label
+	counts      *gtk.Label
+	bio         *gtk.Label
+	bioMarkdown *MarkdownLabel
+	message     *gtk.Button
+	personID    int64
+	postsBox    *gtk.Box
+	commentsBox *gtk.Box
+}
+
+func (pv *ProfileView) SetupProfileView(box *gtk.Box) (err error) {
+	_, err = pv.buildAndSetReferences()
+	if err != nil {
+		return
+	}
+
+	err = pv.SetupPostListView(pv.postsBox)
+	if err != nil {
+		return
+	}
+
+	box.PackStart(pv.profileBox, true, true, 0)
+
+	return
+}
+
+func (pv *ProfileView) SetPerson(person model.PersonModel, myUserID int64) {
+	pv.personID = person.Person.ID
+	pv.message.SetVisible(myUserID != 0 && myUserID != person.Person.ID)
+	pv.displayName.SetText(person.Person.DisplayName.ValueOr(person.Person.Name))
+	pv.username.SetText("@" + person.Person.Name)
+	pv.joined.SetText("Joined " + person.Person.Published.Format("January 2, 2006"))
+	pv.counts.SetText(fmt.Sprintf("%d posts, %d comments", person.Counts.PostCount, person.Counts.CommentCount))
+
+	if person.Person.Bio.IsValid() {
+		pv.bioMarkdown.SetMarkdown(person.Person.Bio.ValueOrZero())
+		pv.bio.Show()
+	} else {
+		pv.bio.Hide()
+	}
+
+	if person.Avatar != nil {
+		utils.SetDirectImage(pv.avatar, person.Avatar, [2]int{profileAvatarSize, profileAvatarSize}, nil)
+	}
+}
+
+func (pv *ProfileView) FillComments(comments []model.CommentModel) {
+	for _, comment := range comments {
+		packCommentWithContext(pv.commentsBox, comment, func(postID int64) {
+			if pv.CommentContextClicked != nil {
+				pv.CommentContextClicked(postID)
+			}
+		})
+	}
+}
+
+func (pv *ProfileView) buildAndSetReferences() (builder *gtk.Builder, err error) {
+	builder, err = gtk.BuilderNewFromString(string(data.ProfileUI))
+	if err != nil {
+		return
+	}
+
+	utils.SetWidgetProperty(builder, "card", func(card *gtk.Box) {
+		utils.ApplyStyle(&card.Widget)
+	})
+
+	pv.avatar, err = utils.GetUIObject[gtk.Image](builder, "avatar")
+	if err != nil {
+		return
+	}
+
+	pv.displayName, err = utils.GetUIObject[gtk.Label](builder, "displayName")
+	if err != nil {
+		return
+	}
+	utils.ApplyStyle(&pv.displayName.Widget)
+
+	pv.username, err = utils.GetUIObject[gtk.Label](builder, "username")
+	if err != nil {
+		return
+	}
+
+	pv.joined, err = utils.GetUIObject[gtk.Label](builder, "joined")
+	if err != nil {
+		return
+	}
+
+	pv.counts, err = utils.GetUIObject[gtk.Label](builder, "counts")
+	if err != nil {
+		return
+	}
+
+	pv.bio, err = utils.GetUIObject[gtk.Label](builder, "bio")
+	if err != nil {
+		return
+	}
+	pv.bioMarkdown = NewMarkdownLabel(pv.bio)
+
+	pv.message, err = utils.GetUIObject[gtk.Button](builder, "message")
+	if err != nil {
+		return
+	}
+	pv.message.Connect("clicked", func() {
+		if pv.MessageClicked != nil {
+			pv.MessageClicked(pv.personID)
+		}
+	})
+
+	pv.postsBox, err = utils.GetUIObject[gtk.Box](builder, "posts")
+	if err != nil {
+		return
+	}
+
+	pv.commentsBox, err = utils.GetUIObject[gtk.Box](builder, "comments")
+	if err != nil {
+		return
+	}
+
+	pv.profileBox, err = utils.GetUIObject[gtk.Box](builder, "profile")
+	if err != nil {
+		return
+	}
+	pv.profileBox.Unparent()
+
+	return
+}
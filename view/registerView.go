@@ -0,0 +1,284 @@
+package view
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+type RegisterView struct {
+	Window                *gtk.Dialog
+	RegisterClicked       func(model.Registration)
+	RefreshCaptchaClicked func()
+
+	instance       model.InstanceModel
+	captchaUUID    string
+	instanceLabel  *gtk.Label
+	username       *gtk.Entry
+	emailLabel     *gtk.Label
+	email          *gtk.Entry
+	password       *gtk.Entry
+	passwordVerify *gtk.Entry
+	question       *gtk.Label
+	questionText   *MarkdownLabel
+	answerScroll   *gtk.ScrolledWindow
+	answer         *gtk.TextView
+	captchaBox     *gtk.Box
+	captchaImage   *gtk.Image
+	refreshCaptcha *gtk.Button
+	captchaLabel   *gtk.Label
+	captchaAnswer  *gtk.Entry
+	showNSFW       *gtk.CheckButton
+	legal          *gtk.Label
+	legalText      *MarkdownLabel
+	status         *gtk.Label
+	register       *gtk.Button
+}
+
+func (rv *RegisterView) SetupRegisterView(parent *gtk.Window) (err error) {
+	_, err = rv.buildAndSetReferences()
+	if err != nil {
+		return
+	}
+
+	rv.Window.SetTransientFor(parent)
+	rv.Window.Show()
+
+	return nil
+}
+
+// SetInstance adapts the form to what the instance asks of new users: an
+// email address, an answer to the application question and/or a captcha.
+func (rv *RegisterView) SetInstance(instance model.InstanceModel) {
+	rv.instance = instance
+
+	rv.instanceLabel.SetText(fmt.Sprintf("Create an account in %s", instance.Name))
+
+	if instance.RequireEmail {
+		rv.emailLabel.SetText("Email")
+	} else {
+		rv.emailLabel.SetText("Email (optional)")
+	}
+
+	requiresApplication := instance.RequiresApplication()
+	rv.question.SetVisible(requiresApplication)
+	rv.answerScroll.SetVisible(requiresApplication)
+	if requiresApplication {
+		rv.questionText.SetMarkdown(instance.ApplicationQuestion)
+	}
+
+	rv.captchaBox.SetVisible(instance.CaptchaEnabled)
+	rv.captchaLabel.SetVisible(instance.CaptchaEnabled)
+	rv.captchaAnswer.SetVisible(instance.CaptchaEnabled)
+
+	rv.legal.SetVisible(instance.LegalInformation != "")
+	if instance.LegalInformation != "" {
+		rv.legalText.SetMarkdown(instance.LegalInformation)
+	}
+}
+
+func (rv *RegisterView) SetCaptcha(captcha model.CaptchaModel) {
+	rv.captchaUUID = captcha.UUID
+	rv.captchaAnswer.SetText("")
+	if captcha.Image != nil {
+		rv.captchaImage.SetFromPixbuf(captcha.Image)
+	}
+}
+
+func (rv *RegisterView) SetBusy(busy bool) {
+	rv.register.SetSensitive(!busy)
+	if busy {
+		rv.ShowMessage("Creating account...")
+	}
+}
+
+func (rv *RegisterView) ShowMessage(message string) {
+	rv.status.SetText(message)
+	rv.status.SetVisible(message != "")
+}
+
+func (rv *RegisterView) DestroyWindow() {
+	rv.Window.Destroy()
+	rv.RegisterClicked = nil
+	rv.RefreshCaptchaClicked = nil
+}
+
+func (rv *RegisterView) buildAndSetReferences() (builder *gtk.Builder, err error) {
+	builder, err = gtk.BuilderNewFromString(string(data.RegisterUI))
+	if err != nil {
+		return
+	}
+
+	rv.Window, err = utils.GetUIObject[gtk.Dialog](builder, "registerDialog")
+	if err != nil {
+		return
+	}
+
+	rv.instanceLabel, err = utils.GetUIObject[gtk.Label](builder, "instance")
+	if err != nil {
+		return
+	}
+
+	rv.username, err = utils.GetUIObject[gtk.Entry](builder, "username")
+	if err != nil {
+		return
+	}
+
+	rv.emailLabel, err = utils.GetUIObject[gtk.Label](builder, "emailLabel")
+	if err != nil {
+		return
+	}
+
+	rv.email, err = utils.GetUIObject[gtk.Entry](builder, "email")
+	if err != nil {
+		return
+	}
+
+	rv.password, err = utils.GetUIObject[gtk.Entry](builder, "password")
+	if err != nil {
+		return
+	}
+
+	rv.passwordVerify, err = utils.GetUIObject[gtk.Entry](builder, "passwordVerify")
+	if err != nil {
+		return
+	}
+
+	rv.question, err = utils.GetUIObject[gtk.Label](builder, "question")
+	if err != nil {
+		return
+	}
+	rv.questionText = NewMarkdownLabel(rv.question)
+
+	rv.answerScroll, err = utils.GetUIObject[gtk.ScrolledWindow](builder, "answerScroll")
+	if err != nil {
+		return
+	}
+
+	rv.answer, err = utils.GetUIObject[gtk.TextView](builder, "answer")
+	if err != nil {
+		return
+	}
+
+	rv.captchaBox, err = utils.GetUIObject[gtk.Box](builder, "captchaBox")
+	if err != nil {
+		return
+	}
+
+	rv.captchaImage, err = utils.GetUIObject[gtk.Image](builder, "captchaImage")
+	if err != nil {
+		return
+	}
+
+	rv.refreshCaptcha, err = utils.GetUIObject[gtk.Button](builder, "refreshCaptcha")
+	if err != nil {
+		return
+	}
+	rv.refreshCaptcha.Connect("clicked", func() {
+		if rv.RefreshCaptchaClicked != nil {
+			rv.RefreshCaptchaClicked()
+		}
+	})
+
+	rv.captchaLabel, err = utils.GetUIObject[gtk.Label](builder, "captchaLabel")
+	if err != nil {
+		return
+	}
+
+	rv.captchaAnswer, err = utils.GetUIObject[gtk.Entry](builder, "captchaAnswer")
+	if err != nil {
+		return
+	}
+
+	rv.showNSFW, err = utils.GetUIObject[gtk.CheckButton](builder, "showNSFW")
+	if err != nil {
+		return
+	}
+
+	rv.legal, err = utils.GetUIObject[gtk.Label](builder, "legal")
+	if err != nil {
+		return
+	}
+	rv.legalText = NewMarkdownLabel(rv.legal)
+
+	rv.status, err = utils.GetUIObject[gtk.Label](builder, "status")
+	if err != nil {
+		return
+	}
+
+	rv.register, err = utils.GetUIObject[gtk.Button](builder, "register")
+	if err != nil {
+		return
+	}
+	rv.register.Connect("clicked", rv.onRegisterClicked)
+
+	return
+}
+
+func (rv *RegisterView) onRegisterClicked() {
+	if rv.RegisterClicked == nil {
+		return
+	}
+
+	var registration model.Registration
+	var err error
+	entries := []struct {
+		entry *gtk.Entry
+		text  *string
+	}{
+		{rv.username, &registration.Username},
+		{rv.email, &registration.Email},
+		{rv.password, &registration.Password},
+		{rv.passwordVerify, &registration.PasswordVerify},
+		{rv.captchaAnswer, &registration.CaptchaAnswer},
+	}
+	for _, field := range entries {
+		*field.text, err = field.entry.GetText()
+		if err != nil {
+			log.Println(err)
+			return
+		}
+	}
+	registration.Username = strings.TrimSpace(registration.Username)
+	registration.Email = strings.TrimSpace(registration.Email)
+
+	buffer, err := rv.answer.GetBuffer()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	registration.Answer, err = buffer.GetText(buffer.GetStartIter(), buffer.GetEndIter(), false)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	registration.Answer = strings.TrimSpace(registration.Answer)
+
+	switch {
+	case registration.Username == "":
+		rv.ShowMessage("Choose a username.")
+		return
+	case rv.instance.RequireEmail && registration.Email == "":
+		rv.ShowMessage("This instance requires an email address.")
+		return
+	case registration.Password != registration.PasswordVerify:
+		rv.ShowMessage("The passwords don't match.")
+		return
+	case rv.instance.RequiresApplication() && registration.Answer == "":
+		rv.ShowMessage("Answer the instance's application question.")
+		return
+	}
+
+	if rv.instance.CaptchaEnabled {
+		registration.CaptchaUUID = rv.captchaUUID
+	}
+	registration.ShowNSFW = rv.showNSFW.GetActive()
+
+	rv.SetBusy(true)
+	rv.RegisterClicked(registration)
+}
@@ -0,0 +1,68 @@
+package view
+
+import (
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+type SavedView struct {
+	PostListView
+	CommentContextClicked func(int64)
+
+	saved       *gtk.Notebook
+	postsBox    *gtk.Box
+	commentsBox *gtk.Box
+}
+
+func (sv *SavedView) SetupSavedView(box *gtk.Box) (err error) {
+	_, err = sv.buildAndSetReferences()
+	if err != nil {
+		return
+	}
+
+	err = sv.SetupPostListView(sv.postsBox)
+	if err != nil {
+		return
+	}
+
+	box.PackStart(sv.saved, true, true, 0)
+
+	return
+}
+
+func (sv *SavedView) FillComments(comments []model.CommentModel) {
+	for _, comment := range comments {
+		packCommentWithContext(sv.commentsBox, comment, func(postID int64) {
+			if sv.CommentContextClicked != nil {
+				sv.CommentContextClicked(postID)
+			}
+		})
+	}
+}
+
+func (sv *SavedView) buildAndSetReferences() (builder *gtk.Builder, err error) {
+	builder, err = gtk.BuilderNewFromString(string(data.SavedUI))
+	if err != nil {
+		return
+	}
+
+	sv.postsBox, err = utils.GetUIObject[gtk.Box](builder, "posts")
+	if err != nil {
+		return
+	}
+
+	sv.commentsBox, err = utils.GetUIObject[gtk.Box](builder, "comments")
+	if err != nil {
+		return
+	}
+
+	sv.saved, err = utils.GetUIObject[gtk.Notebook](builder, "saved")
+	if err != nil {
+		return
+	}
+	sv.saved.Unparent()
+
+	return
+}
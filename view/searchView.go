@@ -0,0 +1,223 @@
+package view
+
+import (
+	"fmt"
+	"html"
+	"log"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/model"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+type SearchView struct {
+	PostListView          PostListView
+	CommentContextClicked func(int64)
+
+	parentBox          *gtk.Box
+	searchResults      *gtk.Box
+	query              *gtk.Label
+	noResults          *gtk.Label
+	communitiesSection *gtk.Box
+	communities        *gtk.ListBox
+	usersSection       *gtk.Box
+	users              *gtk.ListBox
+	postsSection       *gtk.Box
+	posts              *gtk.Box
+	commentsSection    *gtk.Box
+	comments           *gtk.Box
+}
+
+func (sv *SearchView) SetupSearchView(box *gtk.Box) (err error) {
+	_, err = sv.buildAndSetReferences()
+	if err != nil {
+		return
+	}
+	sv.parentBox = box
+
+	err = sv.PostListView.SetupPostListView(sv.posts)
+	if err != nil {
+		return
+	}
+
+	sv.parentBox.PackStart(sv.searchResults, false, false, 0)
+
+	return
+}
+
+func (sv *SearchView) StartSearch(query string) {
+	sv.query.SetText(fmt.Sprintf("Results for \"%s\"", query))
+	sv.PostListView.CleanView()
+	removeAllChildren(&sv.communities.Container)
+	removeAllChildren(&sv.users.Container)
+	removeAllChildren(&sv.comments.Container)
+	sv.communitiesSection.Hide()
+	sv.usersSection.Hide()
+	sv.postsSection.Hide()
+	sv.commentsSection.Hide()
+	sv.noResults.Hide()
+}
+
+func (sv *SearchView) FillPostsData(posts []model.PostModel) {
+	if len(posts) > 0 {
+		sv.postsSection.Show()
+		sv.noResults.Hide()
+	}
+	sv.PostListView.FillPostsData(posts, false)
+}
+
+func (sv *SearchView) FillResults(communities []model.CommunityModel, users []model.PersonModel, comments []model.CommentModel) {
+	for _, community := range communities {
+		addResultRow(sv.communities, fmt.Sprintf("<b>%s</b>  !%s  <i>%d subscribers</i>",
+			html.EscapeString(community.Community.Title), html.EscapeString(community.Community.Name), community.Counts.Subscribers))
+		sv.communitiesSection.Show()
+	}
+
+	for _, user := range users {
+		addResultRow(sv.users, fmt.Sprintf("<b>%s</b>  @%s  <i>%d posts, %d comments</i>",
+			html.EscapeString(user.Person.DisplayName.ValueOr(user.Person.Name)), html.EscapeString(user.Person.Name),
+			user.Counts.PostCount, user.Counts.CommentCount))
+		sv.usersSection.Show()
+	}
+
+	for _, comment := range comments {
+		sv.addComment(comment)
+		sv.commentsSection.Show()
+	}
+}
+
+func (sv *SearchView) ShowNoResultsIfEmpty() {
+	if !sv.communitiesSection.GetVisible() && !sv.usersSection.GetVisible() &&
+		!sv.postsSection.GetVisible() && !sv.commentsSection.GetVisible() {
+		sv.noResults.Show()
+	}
+}
+
+func (sv *SearchView) Destroy() {
+	sv.parentBox.Remove(sv.searchResults)
+}
+
+func (sv *SearchView) addComment(comment model.CommentModel) {
+	packCommentWithContext(sv.comments, comment, func(postID int64) {
+		if sv.CommentContextClicked != nil {
+			sv.CommentContextClicked(postID)
+		}
+	})
+}
+
+func (sv *SearchView) buildAndSetReferences() (builder *gtk.Builder, err error) {
+	builder, err = gtk.BuilderNewFromString(string(data.SearchUI))
+	if err != nil {
+		return
+	}
+
+	sv.searchResults, err = utils.GetUIObject[gtk.Box](builder, "searchResults")
+	if err != nil {
+		return
+	}
+
+	sv.query, err = utils.GetUIObject[gtk.Label](builder, "query")
+	if err != nil {
+		return
+	}
+	utils.ApplyStyle(&sv.query.Widget)
+
+	sv.noResults, err = utils.GetUIObject[gtk.Label](builder, "noResults")
+	if err != nil {
+		return
+	}
+
+	sv.communitiesSection, err = utils.GetUIObject[gtk.Box](builder, "communitiesSection")
+	if err != nil {
+		return
+	}
+
+	sv.communities, err = utils.GetUIObject[gtk.ListBox](builder, "communities")
+	if err != nil {
+		return
+	}
+
+	sv.usersSection, err = utils.GetUIObject[gtk.Box](builder, "usersSection")
+	if err != nil {
+		return
+	}
+
+	sv.users, err = utils.GetUIObject[gtk.ListBox](builder, "users")
+	if err != nil {
+		return
+	}
+
+	sv.postsSection, err = utils.GetUIObject[gtk.Box](builder, "postsSection")
+	if err != nil {
+		return
+	}
+
+	sv.posts, err = utils.GetUIObject[gtk.Box](builder, "posts")
+	if err != nil {
+		return
+	}
+
+	sv.commentsSection, err = utils.GetUIObject[gtk.Box](builder, "commentsSection")
+	if err != nil {
+		return
+	}
+
+	sv.comments, err = utils.GetUIObject[gtk.Box](builder, "comments")
+	if err != nil {
+		return
+	}
+
+	sv.searchResults.Unparent()
+
+	return
+}
+
+func addResultRow(list *gtk.ListBox, markup string) {
+	label, err := gtk.LabelNew("")
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	label.SetMarkup(markup)
+	label.SetXAlign(0)
+	label.SetLineWrap(true)
+	label.SetMarginTop(3)
+	label.SetMarginBottom(3)
+	label.Show()
+	list.Add(label)
+}
+
+func removeAllChildren(container *gtk.Container) {
+	container.GetChildren().Foreach(func(child interface{}) {
+		widget, ok := child.(gtk.IWidget)
+		if ok {
+			container.Remove(widget)
+		}
+	})
+}
+
+func packCommentWithContext(box *gtk.Box, comment model.CommentModel, contextClicked func(int64)) {
+	commentView, err := NewCommentView(comment, false)
+	if err != nil {
+		log.Printf("Error creating comment UI for %d", comment.Comment.ID)
+		return
+	}
+	commentView.SetReadOnly()
+
+	context, err := gtk.ButtonNewWithLabel(fmt.Sprintf("in %s", comment.Post.Name))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	context.SetRelief(gtk.RELIEF_NONE)
+	context.SetHAlign(gtk.ALIGN_START)
+	postID := comment.Post.ID
+	context.Connect("clicked", func() {
+		contextClicked(postID)
+	})
+	context.Show()
+
+	box.PackStart(context, false, false, 0)
+	box.PackStart(commentView.CommentBox, true, false, 5)
+}
@@ -0,0 +1,183 @@
+package view
+
+import (
+	"log"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/mjdiliscia/LemmeRead/data"
+	"github.com/mjdiliscia/LemmeRead/utils"
+)
+
+const videoHeight = maxPostImageSize * 9 / 16
+
+// VideoView shows the poster of a video until it's played. The player is only
+// created then, so a feed full of videos doesn't open a pipeline for each.
+type VideoView struct {
+	Video *gtk.Box
+
+	uri           string
+	player        *utils.VideoPlayer
+	playing       bool
+	poster        *gtk.Image
+	screen        *gtk.Box
+	playPause     *gtk.Button
+	playPauseIcon *gtk.Image
+	mute          *gtk.ToggleButton
+	muteIcon      *gtk.Image
+	status        *gtk.Label
+}
+
+func NewVideoView(uri string, poster *gdk.Pixbuf) (vv *VideoView, err error) {
+	vv = &VideoView{uri: uri}
+	_, err = vv.buildAndSetReferences()
+	if err != nil {
+		return
+	}
+
+	if poster != nil {
+		utils.SetDirectImage(vv.poster, poster, [2]int{maxPostImageSize, maxPostImageSize}, nil)
+	}
+
+	return
+}
+
+func (vv *VideoView) Destroy() {
+	if vv.player != nil {
+		vv.player.Destroy()
+		vv.player = nil
+	}
+}
+
+func (vv *VideoView) buildAndSetReferences() (builder *gtk.Builder, err error) {
+	builder, err = gtk.BuilderNewFromString(string(data.VideoUI))
+	if err != nil {
+		return
+	}
+
+	vv.Video, err = utils.GetUIObject[gtk.Box](builder, "video")
+	if err != nil {
+		return
+	}
+	// Nothing should keep playing once the post isn't on screen anymore.
+	vv.Video.Connect("unmap", vv.pause)
+	vv.Video.Connect("destroy", vv.Destroy)
+
+	vv.poster, err = utils.GetUIObject[gtk.Image](builder, "poster")
+	if err != nil {
+		return
+	}
+
+	vv.screen, err = utils.GetUIObject[gtk.Box](builder, "screen")
+	if err != nil {
+		return
+	}
+
+	vv.playPause, err = utils.GetUIObject[gtk.Button](builder, "playPause")
+	if err != nil {
+		return
+	}
+	vv.playPause.Connect("clicked", func() {
+		if vv.playing {
+			vv.pause()
+		} else {
+			vv.play()
+		}
+	})
+
+	vv.playPauseIcon, err = utils.GetUIObject[gtk.Image](builder, "playPauseIcon")
+	if err != nil {
+		return
+	}
+
+	vv.mute, err = utils.GetUIObject[gtk.ToggleButton](builder, "mute")
+	if err != nil {
+		return
+	}
+	vv.mute.Connect("toggled", vv.onMuteToggled)
+
+	vv.muteIcon, err = utils.GetUIObject[gtk.Image](builder, "muteIcon")
+	if err != nil {
+		return
+	}
+
+	vv.status, err = utils.GetUIObject[gtk.Label](builder, "status")
+	if err != nil {
+		return
+	}
+
+	vv.Video.Unparent()
+
+	return
+}
+
+func (vv *VideoView) play() {
+	if vv.player == nil {
+		player, err := utils.NewVideoPlayer(vv.uri)
+		if err != nil {
+			vv.showError(err)
+			return
+		}
+		vv.player = player
+		vv.player.Ended = func() {
+			vv.setPlaying(false)
+		}
+		vv.player.Failed = func(err error) {
+			vv.showError(err)
+			vv.setPlaying(false)
+		}
+		vv.player.SetMuted(vv.mute.GetActive())
+		vv.player.Widget.SetSizeRequest(maxPostImageSize, videoHeight)
+		vv.screen.PackStart(vv.player.Widget, true, true, 0)
+		vv.player.Widget.Show()
+	}
+
+	err := vv.player.Play()
+	if err != nil {
+		vv.showError(err)
+		return
+	}
+
+	vv.poster.Hide()
+	vv.screen.Show()
+	vv.status.Hide()
+	vv.setPlaying(true)
+}
+
+func (vv *VideoView) pause() {
+	if vv.player != nil && vv.playing {
+		vv.player.Pause()
+	}
+	vv.setPlaying(false)
+}
+
+func (vv *VideoView) setPlaying(playing bool) {
+	vv.playing = playing
+	if playing {
+		vv.playPauseIcon.SetFromIconName("media-playback-pause-symbolic", gtk.ICON_SIZE_BUTTON)
+		vv.playPause.SetTooltipText("Pause")
+	} else {
+		vv.playPauseIcon.SetFromIconName("media-playback-start-symbolic", gtk.ICON_SIZE_BUTTON)
+		vv.playPause.SetTooltipText("Play")
+	}
+}
+
+func (vv *VideoView) onMuteToggled() {
+	muted := vv.mute.GetActive()
+	if vv.player != nil {
+		vv.player.SetMuted(muted)
+	}
+	if muted {
+		vv.muteIcon.SetFromIconName("audio-volume-muted-symbolic", gtk.ICON_SIZE_BUTTON)
+		vv.mute.SetTooltipText("Unmute")
+	} else {
+		vv.muteIcon.SetFromIconName("audio-volume-high-symbolic", gtk.ICON_SIZE_BUTTON)
+		vv.mute.SetTooltipText("Mute")
+	}
+}
+
+func (vv *VideoView) showError(err error) {
+	log.Printf("Couldn't play '%s': %s", vv.uri, err)
+	vv.status.SetText("The video can't be played here, open the link instead.")
+	vv.status.Show()
+}